@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/akumor/elmercrawl/pkg/elmercrawl"
+)
+
+// checkpointFile keeps the partition crawl checkpoints of every target of a
+// run in one file, keyed like incremental states by region and catalog ID.
+type checkpointFile struct {
+	path string
+	mu   sync.Mutex
+	// targets holds each target's last saved checkpoint, encoded so that the
+	// file can be written while other targets' crawls update theirs.
+	targets map[string]json.RawMessage
+}
+
+// loadCheckpoints hands each target's crawler a checkpoint that is saved to
+// path every interval. With resume the checkpoints are read from path, which
+// must exist; otherwise every target starts from the beginning.
+func loadCheckpoints(path string, resume bool, interval time.Duration, targets []*target) (*checkpointFile, error) {
+	f := &checkpointFile{path: path, targets: map[string]json.RawMessage{}}
+	if resume {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no checkpoint to resume from at %s", path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read checkpoint file: %w", err)
+		}
+		err = json.Unmarshal(data, &f.targets)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse checkpoint file %s: %w", path, err)
+		}
+	}
+	for _, t := range targets {
+		key := t.stateKey()
+		checkpoint := &elmercrawl.Checkpoint{}
+		if data, ok := f.targets[key]; ok {
+			err := json.Unmarshal(data, checkpoint)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse checkpoint of %s in %s: %w", key, path, err)
+			}
+		}
+		t.crawler.Checkpoint = checkpoint
+		t.crawler.CheckpointInterval = interval
+		t.crawler.SaveCheckpoint = func(cp *elmercrawl.Checkpoint) error {
+			return f.save(key, cp)
+		}
+	}
+	return f, nil
+}
+
+// save records the checkpoint of the target with key and replaces the file.
+func (f *checkpointFile) save(key string, cp *elmercrawl.Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("unable to encode checkpoint: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.targets[key] = data
+	data, err = json.MarshalIndent(f.targets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode checkpoint: %w", err)
+	}
+	err = replaceFile(f.path, data)
+	if err != nil {
+		return fmt.Errorf("unable to write checkpoint file: %w", err)
+	}
+	return nil
+}
+
+// remove deletes the file once every target finished.
+func (f *checkpointFile) remove() error {
+	err := os.Remove(f.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to remove checkpoint file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/akumor/elmercrawl/pkg/elmercrawl"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// confirm asks prompt on w and reports whether the answer read from r was
+// yes. Anything else, including no answer at all, declines.
+func confirm(r io.Reader, w io.Writer, prompt string) bool {
+	fmt.Fprint(w, prompt)
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// printPreview lists the partitions of a dry run batch.
+func printPreview(t *target, batch *elmercrawl.PartitionDeleteBatch) {
+	for _, partition := range batch.Partitions {
+		fmt.Printf("%sWould delete partition %s/%s\n", t.label, batch.Object(), strings.Join(aws.StringValueSlice(partition.Values), "/"))
+	}
+}
+
+// printDeleteBatch reports the outcome of one BatchDeletePartition request.
+// The partitions Glue failed to delete are listed with the other failures
+// once every batch ran.
+func printDeleteBatch(t *target, batch *elmercrawl.PartitionDeleteBatch) {
+	if batch.Err != nil {
+		fmt.Printf("%sFailed to delete %d partitions of %s: %v\n", t.label, len(batch.Partitions), batch.Object(), batch.Err)
+		return
+	}
+	deleted := len(batch.Partitions) - len(batch.Errors)
+	if len(batch.Errors) != 0 {
+		fmt.Printf("%sDeleted %d of %d partitions of %s, %d failed\n", t.label, deleted, len(batch.Partitions), batch.Object(), len(batch.Errors))
+		return
+	}
+	fmt.Printf("%sDeleted %d partitions of %s\n", t.label, deleted, batch.Object())
+}
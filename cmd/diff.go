@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/akumor/elmercrawl/pkg/elmercrawl"
+)
+
+// liveSide is the diff side that crawls the catalog given by the root flags.
+// It may be followed by :REGION, and /CATALOG_ID or /CATALOG_ID=ROLE_ARN, to
+// crawl another catalog.
+const liveSide = "live"
+
+// exitError makes the program exit with code. A nil err means the command
+// already reported the outcome and nothing more is printed.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// diffSideOpts returns the root options that load one side of a diff, which
+// is either a live catalog or a snapshot file. Live sides always crawl AWS,
+// even when --from-snapshot is set.
+func diffSideOpts(opts RootOpts, side string) RootOpts {
+	if side == liveSide {
+		opts.FromSnapshot = ""
+		return opts
+	}
+	if strings.HasPrefix(side, liveSide+":") {
+		opts.FromSnapshot = ""
+		region, catalog, _ := strings.Cut(strings.TrimPrefix(side, liveSide+":"), "/")
+		opts.AWSRegions = []string{region}
+		opts.AllRegions = false
+		opts.Catalogs = nil
+		if catalog != "" {
+			opts.Catalogs = []string{catalog}
+		}
+		return opts
+	}
+	opts.FromSnapshot = side
+	return opts
+}
+
+// loadDiffSide crawls one side of a diff into a snapshot.
+func loadDiffSide(ctx context.Context, opts RootOpts, side string) (*elmercrawl.Snapshot, error) {
+	targets, err := getTargets(diffSideOpts(opts, side))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create crawler for %s: %w", side, err)
+	}
+	if len(targets) != 1 {
+		return nil, fmt.Errorf("diff takes a single region and catalog for %s, got %d", side, len(targets))
+	}
+	fmt.Fprintf(os.Stderr, "Crawling %s...\n", side)
+	snapshot, err := targets[0].crawler.Snapshot(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to crawl %s: %w", side, err)
+	}
+	return snapshot, nil
+}
+
+// printChanges writes changes to stdout as text, one per line, or as a JSON
+// array.
+func printChanges(changes []elmercrawl.Change, format string) error {
+	switch format {
+	case "text":
+		for i := range changes {
+			fmt.Println(changes[i].String())
+		}
+		return nil
+	case "json":
+		if changes == nil {
+			changes = []elmercrawl.Change{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	}
+	return fmt.Errorf("unknown diff format %q, expected text or json", format)
+}
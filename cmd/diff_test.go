@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestDiffSideOpts(t *testing.T) {
+	opts := RootOpts{FromSnapshot: "old.json", AWSRegions: []string{"us-east-1"}}
+	cases := []struct {
+		Side         string
+		FromSnapshot string
+		Region       string
+	}{
+		{Side: "live", FromSnapshot: "", Region: "us-east-1"},
+		{Side: "live:eu-west-1/123", FromSnapshot: "", Region: "eu-west-1"},
+		{Side: "new.json", FromSnapshot: "new.json", Region: "us-east-1"},
+	}
+	for _, c := range cases {
+		got := diffSideOpts(opts, c.Side)
+		if got.FromSnapshot != c.FromSnapshot || got.AWSRegions[0] != c.Region {
+			t.Fatalf("%s, expected snapshot %q in %s, got %q in %v", c.Side, c.FromSnapshot, c.Region, got.FromSnapshot, got.AWSRegions)
+		}
+	}
+}
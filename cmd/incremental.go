@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/akumor/elmercrawl/pkg/elmercrawl"
+)
+
+// incrementalStates are the incremental crawl states of every target of a
+// run, keyed by region and catalog ID.
+type incrementalStates map[string]*elmercrawl.IncrementalState
+
+func (t *target) stateKey() string {
+	return t.Region + "/" + t.CatalogId
+}
+
+// loadIncrementalStates reads the state file at path and hands each target's
+// crawler its state. A missing file starts every target with a full crawl.
+func loadIncrementalStates(path string, targets []*target) (incrementalStates, error) {
+	states := incrementalStates{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unable to read incremental state file: %w", err)
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &states)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse incremental state file %s: %w", path, err)
+		}
+	}
+	for _, t := range targets {
+		state, ok := states[t.stateKey()]
+		if !ok {
+			state = &elmercrawl.IncrementalState{}
+			states[t.stateKey()] = state
+		}
+		t.crawler.Incremental = state
+	}
+	return states, nil
+}
+
+// save replaces the state file at path.
+func (s incrementalStates) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode incremental state: %w", err)
+	}
+	err = replaceFile(path, data)
+	if err != nil {
+		return fmt.Errorf("unable to write incremental state file: %w", err)
+	}
+	return nil
+}
+
+// saveAfterFailures saves the states at path after a crawl that kept going
+// past the failures err reports as a *MultiError. The targets that failed
+// kept their previous state while the others were updated. A crawl stopped
+// by any other error saves nothing.
+func (s incrementalStates) saveAfterFailures(path string, err error) error {
+	var multiErr *elmercrawl.MultiError
+	if s == nil || !errors.As(err, &multiErr) {
+		return nil
+	}
+	return s.save(path)
+}
+
+// replaceFile writes data to a temporary file next to path and renames it
+// over path, so that a failed write keeps the previous contents.
+func replaceFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// printDeleted reports the objects of kind that disappeared since the
+// previous incremental run of each target.
+func printDeleted(targets []*target, kind string, level func(*elmercrawl.IncrementalState) *elmercrawl.IncrementalLevel) {
+	for _, t := range targets {
+		for _, key := range level(t.crawler.Incremental).Deleted {
+			fmt.Printf("%sDeleted %s %s\n", t.label, kind, key)
+		}
+	}
+}
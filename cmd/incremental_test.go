@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akumor/elmercrawl/pkg/elmercrawl"
+)
+
+func TestSaveAfterFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	states := incrementalStates{"us-east-1/": &elmercrawl.IncrementalState{}}
+
+	err := states.saveAfterFailures(path, errors.New("boom"))
+	if _, statErr := os.Stat(path); err != nil || !errors.Is(statErr, os.ErrNotExist) {
+		t.Fatalf("expected a stopped crawl to save nothing, got %v and %v", err, statErr)
+	}
+
+	failures := &elmercrawl.MultiError{Errors: []*elmercrawl.ObjectError{{Region: "eu-west-1", Err: errors.New("boom")}}}
+	err = states.saveAfterFailures(path, failures)
+	if _, statErr := os.Stat(path); err != nil || statErr != nil {
+		t.Fatalf("expected a crawl that kept going to save its states, got %v and %v", err, statErr)
+	}
+}
@@ -1,24 +1,44 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
-	"text/template"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/akumor/elmercrawl/pkg/elmercrawl"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/glue/glueiface"
 	"github.com/spf13/cobra"
 )
 
 const version string = "0.0.1"
 
 type RootOpts struct {
-	AWSRegion string
-	CatalogId string
+	AWSRegions []string
+	AllRegions bool
+	CatalogId  string
+	Catalogs   []string
+	Stream     bool
+	Workers    int
+	Parallel   int
+	RateLimit  float64
+	APILimits  map[string]string
+	Retry      elmercrawl.RetryPolicy
+	KeepGoing  bool
+	// FromSnapshot serves the crawl from a snapshot file instead of AWS.
+	FromSnapshot string
+
+	IncludeDatabases []string
+	ExcludeDatabases []string
+	IncludeTables    []string
+	ExcludeTables    []string
 }
 
 func main() {
@@ -29,55 +49,68 @@ func main() {
 		Use:     "elmercrawl",
 		Short:   "Perform operations against resources in an AWS glue data catalog",
 		Version: version,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if rootOpts.FromSnapshot != "" && cmd.Annotations[snapshotAnnotation] == "" {
+				return fmt.Errorf("%s does not support --from-snapshot", cmd.Name())
+			}
+			// Snapshots hold no partition key types to evaluate a GetPartitions
+			// expression with.
+			if expression := cmd.Flags().Lookup("expression"); rootOpts.FromSnapshot != "" && expression != nil && expression.Value.String() != "" {
+				return fmt.Errorf("%s does not support --expression with --from-snapshot, filter the snapshot with --include-table or --exclude-table instead", cmd.Name())
+			}
+			return nil
+		},
 	}
 
-	rootCmd.Flags().StringVarP(&rootOpts.AWSRegion, "aws-region", "p", "us-east-1", "AWS region for the glue data catalog")
-	rootCmd.Flags().StringVarP(&rootOpts.CatalogId, "catalog-id", "C", "", "ID of the AWS Glue Data Catalog to target")
+	rootCmd.PersistentFlags().StringArrayVarP(&rootOpts.AWSRegions, "aws-region", "p", []string{"us-east-1"}, "AWS region for the glue data catalog, may be repeated to crawl several regions concurrently")
+	rootCmd.PersistentFlags().BoolVar(&rootOpts.AllRegions, "all-regions", false, "Crawl every AWS region that offers AWS Glue in the partition of --aws-region concurrently, reporting regions that fail instead of stopping")
+	rootCmd.PersistentFlags().StringVar(&rootOpts.FromSnapshot, "from-snapshot", "", "Crawl the catalog saved in this snapshot file by the export command instead of AWS")
+	rootCmd.PersistentFlags().StringVarP(&rootOpts.CatalogId, "catalog-id", "C", "", "ID of the AWS Glue Data Catalog to target")
+	rootCmd.PersistentFlags().StringArrayVar(&rootOpts.Catalogs, "catalog", nil, "Crawl this catalog ID, optionally through an assumed IAM role given as ID=ROLE_ARN, may be repeated")
+	rootCmd.PersistentFlags().BoolVar(&rootOpts.Stream, "stream", false, "Run the command on each page of results as it arrives instead of listing everything first")
+	rootCmd.PersistentFlags().IntVar(&rootOpts.Workers, "workers", 1, "Number of databases or tables to list in parallel")
+	rootCmd.PersistentFlags().IntVar(&rootOpts.Parallel, "parallel", 1, "Number of commands to run in parallel")
+	rootCmd.PersistentFlags().Float64Var(&rootOpts.RateLimit, "rate-limit", 0, "Maximum requests per second for each Glue API, 0 for no limit")
+	rootCmd.PersistentFlags().StringToStringVar(&rootOpts.APILimits, "api-rate-limit", nil, "Maximum requests per second for specific Glue APIs, e.g. GetPartitions=5")
+	rootCmd.PersistentFlags().BoolVar(&rootOpts.KeepGoing, "keep-going", false, "Keep running the command for remaining objects after a failure and report all failures at the end")
+	rootCmd.PersistentFlags().StringArrayVar(&rootOpts.IncludeDatabases, "include-database", nil, "Only crawl databases matching this glob, or regular expression when prefixed with re:, may be repeated")
+	rootCmd.PersistentFlags().StringArrayVar(&rootOpts.ExcludeDatabases, "exclude-database", nil, "Skip databases matching this glob, or regular expression when prefixed with re:, may be repeated")
+	rootCmd.PersistentFlags().StringArrayVar(&rootOpts.IncludeTables, "include-table", nil, "Only crawl tables matching this glob, or regular expression when prefixed with re:, may be repeated")
+	rootCmd.PersistentFlags().StringArrayVar(&rootOpts.ExcludeTables, "exclude-table", nil, "Skip tables matching this glob, or regular expression when prefixed with re:, may be repeated")
+	rootCmd.PersistentFlags().IntVar(&rootOpts.Retry.MaxAttempts, "max-attempts", 1, "Maximum number of tries for each page of Glue results")
+	rootCmd.PersistentFlags().DurationVar(&rootOpts.Retry.BaseDelay, "retry-base-delay", time.Second, "Delay before the first retry of a failed page, doubled for each further retry")
+	rootCmd.PersistentFlags().DurationVar(&rootOpts.Retry.MaxDelay, "retry-max-delay", 30*time.Second, "Maximum delay between retries of a failed page")
+	rootCmd.PersistentFlags().Float64Var(&rootOpts.Retry.Jitter, "retry-jitter", 0.5, "Fraction of each retry delay that is randomized")
+	rootCmd.PersistentFlags().StringSliceVar(&rootOpts.Retry.RetryableCodes, "retry-code", nil, "AWS error code to retry, may be repeated (default ThrottlingException, InternalServiceException, OperationTimeoutException, RequestError)")
 
 	databasesCmd := &cobra.Command{
-		Use:   "databases [command]",
-		Short: "Run some command against every database in the specified AWS glue data catalog",
-		Args:  cobra.MaximumNArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
+		Use:         "databases [command]",
+		Annotations: snapshotSupported,
+		Short:       "Run some command against every database in the specified AWS glue data catalog",
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
 			command := ""
 			if len(args) != 0 {
 				command = args[0]
 			}
-			crawler, err := getCrawler(rootOpts.AWSRegion, rootOpts.CatalogId)
+			targets, err := getTargets(rootOpts)
 			if err != nil {
 				return fmt.Errorf("unable to create crawler: %w", err)
 			}
+			runner, err := newObjectRunner("databases", command)
+			if err != nil {
+				return err
+			}
 			fmt.Println("Crawling databases...")
-			err = crawler.CrawlDatabases(func(db *glue.Database) error {
-				if command == "" {
-					fmt.Println(*db.Name)
-				} else {
-					tmpl, err := template.New("databases").Parse(command)
-					if err != nil {
-						return fmt.Errorf("failed to parse databases command template: %w", err)
-					}
-					buf := new(bytes.Buffer)
-					err = tmpl.Execute(buf, *db)
-					if err != nil {
-						return fmt.Errorf("failed to render databases command template: %w", err)
-					}
-					cmd := exec.Command("bash", "-c", buf.String())
-					var stdout bytes.Buffer
-					var stderr bytes.Buffer
-					cmd.Stdout = &stdout
-					cmd.Stderr = &stderr
-					err = cmd.Run()
-					if err != nil {
-						return fmt.Errorf("failed to run databases function: %w", err)
-					}
-					fmt.Println("--- stdout ---")
-					fmt.Println(stdout.String())
-					fmt.Println("--- stderr ---")
-					fmt.Println(stderr.String())
-				}
-				return nil
+			err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+				return t.crawler.CrawlDatabasesWithContext(ctx, func(db *glue.Database) error {
+					return runner.run(ctx, t, *db, *db.Name)
+				})
 			})
 			if err != nil {
+				runner.printPartialProgress(ctx)
+				printFailures(err)
 				return fmt.Errorf("failed to crawl databases: %w", err)
 			}
 			return nil
@@ -86,121 +119,518 @@ func main() {
 
 	rootCmd.AddCommand(databasesCmd)
 
+	var tablesIncremental string
 	tablesCmd := &cobra.Command{
-		Use:   "tables [command]",
-		Short: "Run some command against every table in the specified AWS glue data catalog",
-		Args:  cobra.MaximumNArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
+		Use:         "tables [command]",
+		Annotations: snapshotSupported,
+		Short:       "Run some command against every table in the specified AWS glue data catalog",
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
 			command := ""
 			if len(args) != 0 {
 				command = args[0]
 			}
-			crawler, err := getCrawler(rootOpts.AWSRegion, rootOpts.CatalogId)
+			targets, err := getTargets(rootOpts)
 			if err != nil {
 				return fmt.Errorf("unable to create crawler: %w", err)
 			}
-			fmt.Println("Crawling tables...")
-			err = crawler.CrawlTables(func(table *glue.TableData) error {
-				if command == "" {
-					fmt.Println(*table.Name)
-				} else {
-					tmpl, err := template.New("tables").Parse(command)
-					if err != nil {
-						return fmt.Errorf("failed to parse tables command: %w", err)
-					}
-					buf := new(bytes.Buffer)
-					err = tmpl.Execute(buf, *table)
-					if err != nil {
-						return fmt.Errorf("failed to render tables command: %w", err)
-					}
-					cmd := exec.Command("bash", "-c", buf.String())
-					var stdout bytes.Buffer
-					var stderr bytes.Buffer
-					cmd.Stdout = &stdout
-					cmd.Stderr = &stderr
-					err = cmd.Run()
-					if err != nil {
-						return fmt.Errorf("failed to run tables function: %w", err)
-					}
-					fmt.Println("--- stdout ---")
-					fmt.Println(stdout.String())
-					fmt.Println("--- stderr ---")
-					fmt.Println(stderr.String())
+			var states incrementalStates
+			if tablesIncremental != "" {
+				states, err = loadIncrementalStates(tablesIncremental, targets)
+				if err != nil {
+					return err
 				}
-				return nil
+			}
+			runner, err := newObjectRunner("tables", command)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Crawling tables...")
+			err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+				return t.crawler.CrawlTablesWithContext(ctx, func(table *glue.TableData) error {
+					return runner.run(ctx, t, *table, *table.Name)
+				})
 			})
 			if err != nil {
+				runner.printPartialProgress(ctx)
+				printFailures(err)
+				if saveErr := states.saveAfterFailures(tablesIncremental, err); saveErr != nil {
+					fmt.Fprintln(os.Stderr, saveErr)
+				}
 				return fmt.Errorf("failed to crawl tables: %w", err)
 			}
+			if states != nil {
+				printDeleted(targets, "table", func(s *elmercrawl.IncrementalState) *elmercrawl.IncrementalLevel { return &s.Tables })
+				return states.save(tablesIncremental)
+			}
 			return nil
 		},
 	}
 
+	tablesCmd.Flags().StringVar(&tablesIncremental, "incremental", "", "Only run the command for tables changed since the run that last updated this state file, and report deleted tables")
+
 	rootCmd.AddCommand(tablesCmd)
 
+	tableVersionsCmd := &cobra.Command{
+		Use:         "tableversions [command]",
+		Annotations: snapshotSupported,
+		Short:       "Run some command against every version of every table in the specified AWS glue data catalog",
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			command := ""
+			if len(args) != 0 {
+				command = args[0]
+			}
+			targets, err := getTargets(rootOpts)
+			if err != nil {
+				return fmt.Errorf("unable to create crawler: %w", err)
+			}
+			runner, err := newObjectRunner("table versions", command)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Crawling table versions...")
+			err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+				return t.crawler.CrawlTableVersionsWithContext(ctx, func(version *glue.TableVersion) error {
+					return runner.run(ctx, t, *version, fmt.Sprintf("%s.%s %s", *version.Table.DatabaseName, *version.Table.Name, *version.VersionId))
+				})
+			})
+			if err != nil {
+				runner.printPartialProgress(ctx)
+				printFailures(err)
+				return fmt.Errorf("failed to crawl table versions: %w", err)
+			}
+			return nil
+		},
+	}
+
+	rootCmd.AddCommand(tableVersionsCmd)
+
+	var (
+		partitionExpression   string
+		partitionSegments     int
+		segmentThreshold      int
+		partitionsIncremental string
+		checkpointPath        string
+		checkpointResume      bool
+		checkpointInterval    time.Duration
+	)
 	partitionsCmd := &cobra.Command{
-		Use:   "partitions [command]",
-		Short: "Run some command against every partition in the specified AWS glue data catalog",
-		Args:  cobra.MaximumNArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
+		Use:         "partitions [command]",
+		Annotations: snapshotSupported,
+		Short:       "Run some command against every partition in the specified AWS glue data catalog",
+		Args:        cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
 			command := ""
 			if len(args) != 0 {
 				command = args[0]
 			}
-			crawler, err := getCrawler(rootOpts.AWSRegion, rootOpts.CatalogId)
+			targets, err := getTargets(rootOpts)
 			if err != nil {
 				return fmt.Errorf("unable to create crawler: %w", err)
 			}
+			for _, t := range targets {
+				t.crawler.PartitionExpression = partitionExpression
+				t.crawler.PartitionSegments = partitionSegments
+				t.crawler.SegmentThreshold = segmentThreshold
+			}
+			var states incrementalStates
+			if partitionsIncremental != "" {
+				states, err = loadIncrementalStates(partitionsIncremental, targets)
+				if err != nil {
+					return err
+				}
+			}
+			if checkpointResume && checkpointPath == "" {
+				return errors.New("--resume requires --checkpoint")
+			}
+			var checkpoints *checkpointFile
+			if checkpointPath != "" {
+				if partitionsIncremental != "" || partitionSegments > 1 {
+					return errors.New("--checkpoint cannot be combined with --incremental or --segments")
+				}
+				checkpoints, err = loadCheckpoints(checkpointPath, checkpointResume, checkpointInterval, targets)
+				if err != nil {
+					return err
+				}
+			}
+			runner, err := newObjectRunner("partitions", command)
+			if err != nil {
+				return err
+			}
 			fmt.Println("Crawling partitions...")
-			err = crawler.CrawlPartitions(func(partition *glue.Partition) error {
-				if command == "" {
-					fmt.Printf("%v\n", partition)
-				} else {
-					tmpl, err := template.New("partitions").Parse(command)
-					if err != nil {
-						return fmt.Errorf("failed to parse partitions command template: %w", err)
-					}
-					buf := new(bytes.Buffer)
-					err = tmpl.Execute(buf, *partition)
-					if err != nil {
-						return fmt.Errorf("failed to render partitions command: %w", err)
-					}
-					cmd := exec.Command("bash", "-c", buf.String())
-					var stdout bytes.Buffer
-					var stderr bytes.Buffer
-					cmd.Stdout = &stdout
-					cmd.Stderr = &stderr
-					err = cmd.Run()
-					if err != nil {
-						return fmt.Errorf("failed to run partitions function: %w", err)
-					}
-					fmt.Println("--- stdout ---")
-					fmt.Println(stdout.String())
-					fmt.Println("--- stderr ---")
-					fmt.Println(stderr.String())
+			err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+				return t.crawler.CrawlPartitionsWithContext(ctx, func(partition *glue.Partition) error {
+					return runner.run(ctx, t, *partition, fmt.Sprintf("%v", partition))
+				})
+			})
+			if err != nil {
+				runner.printPartialProgress(ctx)
+				printFailures(err)
+				if checkpoints != nil {
+					fmt.Printf("Progress saved to %s, run again with --resume to continue\n", checkpointPath)
 				}
-				return nil
+				if saveErr := states.saveAfterFailures(partitionsIncremental, err); saveErr != nil {
+					fmt.Fprintln(os.Stderr, saveErr)
+				}
+				return fmt.Errorf("failed to crawl partitions: %w", err)
+			}
+			if checkpoints != nil {
+				return checkpoints.remove()
+			}
+			if states != nil {
+				printDeleted(targets, "partition", func(s *elmercrawl.IncrementalState) *elmercrawl.IncrementalLevel { return &s.Partitions })
+				return states.save(partitionsIncremental)
+			}
+			return nil
+		},
+	}
+
+	partitionsCmd.Flags().StringVar(&partitionExpression, "expression", "", "Only crawl partitions matching this GetPartitions filter expression, e.g. \"year < '2023'\"")
+	partitionsCmd.Flags().IntVar(&partitionSegments, "segments", 0, "Split the partition listing of large tables into this many parallel segments, at most 10")
+	partitionsCmd.Flags().IntVar(&segmentThreshold, "segment-threshold", 0, "Only segment tables with more than this many partitions, 0 to segment every table")
+	partitionsCmd.Flags().StringVar(&partitionsIncremental, "incremental", "", "Only run the command for partitions created or accessed since the run that last updated this state file, and report deleted partitions")
+	partitionsCmd.Flags().StringVar(&checkpointPath, "checkpoint", "", "Save the crawl position to this file so that an interrupted crawl can be resumed, and remove it once the crawl finishes")
+	partitionsCmd.Flags().BoolVar(&checkpointResume, "resume", false, "Continue from the position saved by --checkpoint, skipping databases, tables and partitions already processed")
+	partitionsCmd.Flags().DurationVar(&checkpointInterval, "checkpoint-interval", 30*time.Second, "Minimum time between saves of the checkpoint file")
+
+	var (
+		deleteExpression string
+		deleteDryRun     bool
+		deleteYes        bool
+	)
+	deletePartitionsCmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete the partitions matching an expression from the specified AWS glue data catalog",
+		Long: `Delete the partitions matching an expression from the specified AWS glue data catalog.
+
+The matching partitions are always listed first. With --dry-run nothing is deleted;
+otherwise the listed partitions are deleted with BatchDeletePartition, 25 at a time,
+once the deletion is confirmed or --yes is given. Throttled requests are tried up
+to 5 times even when --max-attempts allows fewer; other errors follow --max-attempts.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if deleteExpression == "" {
+				return errors.New("delete requires --expression")
+			}
+			targets, err := getTargets(rootOpts)
+			if err != nil {
+				return fmt.Errorf("unable to create crawler: %w", err)
+			}
+			for _, t := range targets {
+				t.crawler.PartitionExpression = deleteExpression
+			}
+			fmt.Println("Crawling partitions...")
+			var mu sync.Mutex
+			matches := map[*target][]*glue.Partition{}
+			total := 0
+			err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+				return t.crawler.DeletePartitionsWithContext(ctx, true, func(batch *elmercrawl.PartitionDeleteBatch) error {
+					printPreview(t, batch)
+					mu.Lock()
+					defer mu.Unlock()
+					matches[t] = append(matches[t], batch.Partitions...)
+					total += len(batch.Partitions)
+					return nil
+				})
 			})
 			if err != nil {
+				printFailures(err)
 				return fmt.Errorf("failed to crawl partitions: %w", err)
 			}
+			if total == 0 {
+				fmt.Println("No partitions match")
+				return nil
+			}
+			if deleteDryRun {
+				fmt.Printf("Dry run, %d partitions would be deleted\n", total)
+				return nil
+			}
+			if !deleteYes && !confirm(os.Stdin, os.Stdout, fmt.Sprintf("Delete %d partitions? [y/N] ", total)) {
+				return errors.New("deletion cancelled")
+			}
+			fmt.Println("Deleting partitions...")
+			err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+				return t.crawler.DeletePartitionListWithContext(ctx, matches[t], false, func(batch *elmercrawl.PartitionDeleteBatch) error {
+					printDeleteBatch(t, batch)
+					return nil
+				})
+			})
+			if err != nil {
+				printFailures(err)
+				return fmt.Errorf("failed to delete partitions: %w", err)
+			}
 			return nil
 		},
 	}
 
+	deletePartitionsCmd.Flags().StringVar(&deleteExpression, "expression", "", "Delete the partitions matching this GetPartitions filter expression, e.g. \"year < '2023'\"")
+	deletePartitionsCmd.Flags().BoolVar(&deleteDryRun, "dry-run", false, "Only list the partitions that would be deleted")
+	deletePartitionsCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Delete without asking for confirmation")
+
+	partitionsCmd.AddCommand(deletePartitionsCmd)
 	rootCmd.AddCommand(partitionsCmd)
 
+	functionsCmd := &cobra.Command{
+		Use:   "functions [command]",
+		Short: "Run some command against every user-defined function in the specified AWS glue data catalog",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			command := ""
+			if len(args) != 0 {
+				command = args[0]
+			}
+			targets, err := getTargets(rootOpts)
+			if err != nil {
+				return fmt.Errorf("unable to create crawler: %w", err)
+			}
+			runner, err := newObjectRunner("functions", command)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Crawling functions...")
+			err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+				return t.crawler.CrawlUserDefinedFunctionsWithContext(ctx, func(function *glue.UserDefinedFunction) error {
+					return runner.run(ctx, t, *function, fmt.Sprintf("%s.%s", *function.DatabaseName, *function.FunctionName))
+				})
+			})
+			if err != nil {
+				runner.printPartialProgress(ctx)
+				printFailures(err)
+				return fmt.Errorf("failed to crawl functions: %w", err)
+			}
+			return nil
+		},
+	}
+
+	rootCmd.AddCommand(functionsCmd)
+
+	var showConnectionPasswords bool
+	connectionsCmd := &cobra.Command{
+		Use:   "connections [command]",
+		Short: "Run some command against every connection in the specified AWS glue data catalog",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			command := ""
+			if len(args) != 0 {
+				command = args[0]
+			}
+			targets, err := getTargets(rootOpts)
+			if err != nil {
+				return fmt.Errorf("unable to create crawler: %w", err)
+			}
+			for _, t := range targets {
+				t.crawler.ShowConnectionPasswords = showConnectionPasswords
+			}
+			runner, err := newObjectRunner("connections", command)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Crawling connections...")
+			err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+				return t.crawler.CrawlConnectionsWithContext(ctx, func(connection *glue.Connection) error {
+					return runner.run(ctx, t, *connection, *connection.Name)
+				})
+			})
+			if err != nil {
+				runner.printPartialProgress(ctx)
+				printFailures(err)
+				return fmt.Errorf("failed to crawl connections: %w", err)
+			}
+			return nil
+		},
+	}
+
+	connectionsCmd.Flags().BoolVar(&showConnectionPasswords, "show-passwords", false, "Include connection passwords in the crawled connections")
+
+	rootCmd.AddCommand(connectionsCmd)
+
+	partitionIndexesCmd := &cobra.Command{
+		Use:   "partitionindexes [command]",
+		Short: "Run some command against every partition index of every table in the specified AWS glue data catalog",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			command := ""
+			if len(args) != 0 {
+				command = args[0]
+			}
+			targets, err := getTargets(rootOpts)
+			if err != nil {
+				return fmt.Errorf("unable to create crawler: %w", err)
+			}
+			runner, err := newObjectRunner("partition indexes", command)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Crawling partition indexes...")
+			err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+				return t.crawler.CrawlPartitionIndexesWithContext(ctx, func(index *elmercrawl.PartitionIndex) error {
+					return runner.run(ctx, t, *index, fmt.Sprintf("%s.%s %s", *index.Table.DatabaseName, *index.Table.Name, *index.Index.IndexName))
+				})
+			})
+			if err != nil {
+				runner.printPartialProgress(ctx)
+				printFailures(err)
+				return fmt.Errorf("failed to crawl partition indexes: %w", err)
+			}
+			return nil
+		},
+	}
+
+	rootCmd.AddCommand(partitionIndexesCmd)
+
+	var columnStatsPartitions bool
+	columnStatsCmd := &cobra.Command{
+		Use:   "columnstats [command]",
+		Short: "Run some command against the column statistics of every table or partition in the specified AWS glue data catalog",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			command := ""
+			if len(args) != 0 {
+				command = args[0]
+			}
+			targets, err := getTargets(rootOpts)
+			if err != nil {
+				return fmt.Errorf("unable to create crawler: %w", err)
+			}
+			runner, err := newObjectRunner("column statistics", command)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Crawling column statistics...")
+			if columnStatsPartitions {
+				err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+					return t.crawler.CrawlPartitionColumnStatisticsWithContext(ctx, func(stats *elmercrawl.PartitionColumnStatistics) error {
+						summary := fmt.Sprintf("%s.%s %v: %d columns", *stats.Partition.DatabaseName, *stats.Partition.TableName, aws.StringValueSlice(stats.Partition.Values), len(stats.Statistics))
+						return runner.run(ctx, t, *stats, summary)
+					})
+				})
+			} else {
+				err = forEachTarget(ctx, targets, func(ctx context.Context, t *target) error {
+					return t.crawler.CrawlTableColumnStatisticsWithContext(ctx, func(stats *elmercrawl.TableColumnStatistics) error {
+						summary := fmt.Sprintf("%s.%s: %d columns", *stats.Table.DatabaseName, *stats.Table.Name, len(stats.Statistics))
+						return runner.run(ctx, t, *stats, summary)
+					})
+				})
+			}
+			if err != nil {
+				runner.printPartialProgress(ctx)
+				printFailures(err)
+				return fmt.Errorf("failed to crawl column statistics: %w", err)
+			}
+			return nil
+		},
+	}
+
+	columnStatsCmd.Flags().BoolVar(&columnStatsPartitions, "partitions", false, "Crawl the column statistics of every partition instead of every table")
+
+	rootCmd.AddCommand(columnStatsCmd)
+
+	var (
+		exportFormat        string
+		exportTableVersions bool
+	)
+	exportCmd := &cobra.Command{
+		Use:         "export FILE",
+		Annotations: snapshotSupported,
+		Short:       "Write the databases, tables and partitions of the specified AWS glue data catalog to a snapshot file",
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			targets, err := getTargets(rootOpts)
+			if err != nil {
+				return fmt.Errorf("unable to create crawler: %w", err)
+			}
+			if len(targets) != 1 {
+				return fmt.Errorf("export takes a single region and catalog, got %d", len(targets))
+			}
+			fmt.Println("Exporting catalog...")
+			snapshot, err := targets[0].crawler.Snapshot(ctx, exportTableVersions)
+			if err != nil {
+				return fmt.Errorf("failed to crawl catalog: %w", err)
+			}
+			err = writeSnapshot(args[0], exportFormat, snapshot)
+			if err != nil {
+				return fmt.Errorf("failed to export catalog: %w", err)
+			}
+			fmt.Printf("Exported %d databases, %d tables and %d partitions to %s\n", len(snapshot.Databases), len(snapshot.Tables), len(snapshot.Partitions), args[0])
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVar(&exportFormat, "format", "json", "Snapshot file format, json or ndjson")
+	exportCmd.Flags().BoolVar(&exportTableVersions, "table-versions", false, "Include every version of every table in the snapshot")
+
+	rootCmd.AddCommand(exportCmd)
+
+	var (
+		diffFormat           string
+		diffIgnoreParameters []string
+	)
+	diffCmd := &cobra.Command{
+		Use:   "diff OLD NEW",
+		Short: "Report the databases, tables, columns, partitions and parameters that differ between two catalog states",
+		Long: `Report the databases, tables, columns, partitions and parameters that differ between two catalog states.
+
+OLD and NEW are each a snapshot file written by the export command, or "live" for the
+catalog selected by the global flags, optionally followed by :REGION and /CATALOG_ID or
+/CATALOG_ID=ROLE_ARN, e.g. live:eu-west-1/123456789012.
+
+The exit status is 0 when the catalogs match, 1 when they differ and 2 when either side
+could not be crawled.`,
+		Args:        cobra.ExactArgs(2),
+		Annotations: snapshotSupported,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			cmd.SilenceUsage = true
+			oldSnapshot, err := loadDiffSide(ctx, rootOpts, args[0])
+			if err != nil {
+				return &exitError{code: 2, err: err}
+			}
+			newSnapshot, err := loadDiffSide(ctx, rootOpts, args[1])
+			if err != nil {
+				return &exitError{code: 2, err: err}
+			}
+			changes := elmercrawl.FilterChanges(elmercrawl.DiffSnapshots(oldSnapshot, newSnapshot), diffIgnoreParameters)
+			err = printChanges(changes, diffFormat)
+			if err != nil {
+				return &exitError{code: 2, err: err}
+			}
+			if len(changes) > 0 {
+				cmd.SilenceErrors = true
+				return &exitError{code: 1}
+			}
+			return nil
+		},
+	}
+
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format, text or json")
+	diffCmd.Flags().StringArrayVar(&diffIgnoreParameters, "ignore-parameter", nil, "Ignore changes to this database, table or partition parameter, e.g. transient_lastDdlTime, may be repeated")
+
+	rootCmd.AddCommand(diffCmd)
+
 	testCatalogCmd := &cobra.Command{
 		Use:   "testcatalog",
 		Short: "Create a glue database, table, and partition in the specified AWS glue data catalog for testing",
 		Args:  cobra.MaximumNArgs(0),
-		RunE: func(_ *cobra.Command, args []string) error {
-			crawler, err := getCrawler(rootOpts.AWSRegion, rootOpts.CatalogId)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			targets, err := getTargets(rootOpts)
 			if err != nil {
 				return fmt.Errorf("unable to create crawler: %w", err)
 			}
 			fmt.Println("Setting up test glue catalog...")
-			err = crawler.SetupTestGlueDataCatalog()
+			err = forEachTarget(ctx, targets, func(_ context.Context, t *target) error {
+				return t.crawler.SetupTestGlueDataCatalog()
+			})
 			if err != nil {
 				return fmt.Errorf("testcatalog subcommand failed: %w", err)
 			}
@@ -210,24 +640,58 @@ func main() {
 
 	rootCmd.AddCommand(testCatalogCmd)
 
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		code := 1
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.code
+			if exitErr.err == nil {
+				os.Exit(code)
+			}
+		}
 		fmt.Fprintf(os.Stderr, "FATAL: %v", err)
-		os.Exit(1)
+		os.Exit(code)
 	}
 }
 
-func getCrawler(region, catalogId string) (elmercrawl.Crawler, error) {
-	sess, err := session.NewSession(
-		&aws.Config{
-			Region: aws.String(region),
-		},
-	)
-	if err != nil {
-		return elmercrawl.Crawler{}, fmt.Errorf("unable to create AWS session: %w", err)
+func getCrawler(opts RootOpts, glueAPI glueiface.GlueAPI, catalogId string) (elmercrawl.Crawler, error) {
+	var err error
+	apiLimits := map[string]float64{}
+	for api, limit := range opts.APILimits {
+		apiLimits[api], err = strconv.ParseFloat(limit, 64)
+		if err != nil {
+			return elmercrawl.Crawler{}, fmt.Errorf("invalid rate limit for %s: %w", api, err)
+		}
 	}
 	crawler := elmercrawl.Crawler{
-		Glue:      glue.New(sess),
+		Glue:      glueAPI,
 		CatalogId: catalogId,
+		Stream:    opts.Stream,
+		Workers:   opts.Workers,
+		Parallel:  opts.Parallel,
+		KeepGoing: opts.KeepGoing,
+	}
+	if opts.RateLimit > 0 || len(apiLimits) > 0 {
+		crawler.RateLimiter = elmercrawl.NewRateLimiter(opts.RateLimit, apiLimits)
+	}
+	if len(opts.IncludeDatabases) > 0 || len(opts.ExcludeDatabases) > 0 {
+		crawler.DatabaseFilter, err = elmercrawl.NewNameFilter(opts.IncludeDatabases, opts.ExcludeDatabases)
+		if err != nil {
+			return elmercrawl.Crawler{}, fmt.Errorf("invalid database filter: %w", err)
+		}
+	}
+	if len(opts.IncludeTables) > 0 || len(opts.ExcludeTables) > 0 {
+		crawler.TableFilter, err = elmercrawl.NewNameFilter(opts.IncludeTables, opts.ExcludeTables)
+		if err != nil {
+			return elmercrawl.Crawler{}, fmt.Errorf("invalid table filter: %w", err)
+		}
+	}
+	if opts.Retry.MaxAttempts > 1 {
+		retry := opts.Retry
+		crawler.Retry = &retry
 	}
 	return crawler, nil
 }
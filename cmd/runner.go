@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/akumor/elmercrawl/pkg/elmercrawl"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// objectRunner renders a command template for each crawled object and runs it
+// with bash. Output for one object is printed as a single block so that runs
+// executing in parallel do not interleave.
+type objectRunner struct {
+	kind      string
+	tmpl      *template.Template
+	mu        sync.Mutex
+	processed int
+}
+
+func newObjectRunner(kind, command string) (*objectRunner, error) {
+	r := &objectRunner{kind: kind}
+	if command == "" {
+		return r, nil
+	}
+	tmpl, err := template.New(kind).Parse(command)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s command template: %w", kind, err)
+	}
+	r.tmpl = tmpl
+	return r, nil
+}
+
+// run prints summary when no command template was given, and otherwise
+// executes the template rendered with the fields of object and those of the
+// target it was crawled from.
+func (r *objectRunner) run(ctx context.Context, t *target, object interface{}, summary string) error {
+	if r.tmpl == nil {
+		r.print(t.label + summary + "\n")
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	err := r.tmpl.Execute(buf, templateData(t, object))
+	if err != nil {
+		return fmt.Errorf("failed to render %s command template: %w", r.kind, err)
+	}
+	cmd := exec.CommandContext(ctx, "bash", "-c", buf.String())
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed to run %s function: %w", r.kind, err)
+	}
+	r.print(fmt.Sprintf("%s--- stdout ---\n%s\n--- stderr ---\n%s\n", t.label, stdout.String(), stderr.String()))
+	return nil
+}
+
+// The template data of each kind of object embeds the crawled object, so
+// templates keep its fields and methods, e.g. {{.Name}} or {{.String}}, and
+// adds the Region it was crawled from. Objects without a CatalogId field of
+// their own get one holding the target's catalog ID.
+type (
+	databaseData struct {
+		glue.Database
+		Region string
+	}
+	tableData struct {
+		glue.TableData
+		Region string
+	}
+	partitionData struct {
+		glue.Partition
+		Region string
+	}
+	functionData struct {
+		glue.UserDefinedFunction
+		Region string
+	}
+	tableVersionData struct {
+		glue.TableVersion
+		Region    string
+		CatalogId string
+	}
+	connectionData struct {
+		glue.Connection
+		Region    string
+		CatalogId string
+	}
+	partitionIndexData struct {
+		elmercrawl.PartitionIndex
+		Region    string
+		CatalogId string
+	}
+	tableColumnStatisticsData struct {
+		elmercrawl.TableColumnStatistics
+		Region    string
+		CatalogId string
+	}
+	partitionColumnStatisticsData struct {
+		elmercrawl.PartitionColumnStatistics
+		Region    string
+		CatalogId string
+	}
+)
+
+// templateData wraps a crawled object for rendering a command template. An
+// object whose own CatalogId is unset gets the target's.
+func templateData(t *target, object interface{}) interface{} {
+	catalogId := func(id *string) *string {
+		if id == nil && t.CatalogId != "" {
+			return aws.String(t.CatalogId)
+		}
+		return id
+	}
+	switch o := object.(type) {
+	case glue.Database:
+		o.CatalogId = catalogId(o.CatalogId)
+		return databaseData{o, t.Region}
+	case glue.TableData:
+		o.CatalogId = catalogId(o.CatalogId)
+		return tableData{o, t.Region}
+	case glue.Partition:
+		o.CatalogId = catalogId(o.CatalogId)
+		return partitionData{o, t.Region}
+	case glue.UserDefinedFunction:
+		o.CatalogId = catalogId(o.CatalogId)
+		return functionData{o, t.Region}
+	case glue.TableVersion:
+		return tableVersionData{o, t.Region, t.CatalogId}
+	case glue.Connection:
+		return connectionData{o, t.Region, t.CatalogId}
+	case elmercrawl.PartitionIndex:
+		return partitionIndexData{o, t.Region, t.CatalogId}
+	case elmercrawl.TableColumnStatistics:
+		return tableColumnStatisticsData{o, t.Region, t.CatalogId}
+	case elmercrawl.PartitionColumnStatistics:
+		return partitionColumnStatisticsData{o, t.Region, t.CatalogId}
+	}
+	return object
+}
+
+func (r *objectRunner) print(block string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fmt.Print(block)
+	r.processed++
+}
+
+// printPartialProgress reports how far a crawl got when it was interrupted.
+func (r *objectRunner) printPartialProgress(ctx context.Context) {
+	if ctx.Err() == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fmt.Fprintf(os.Stderr, "Interrupted: processed %d %s before stopping\n", r.processed, r.kind)
+}
+
+// printFailures prints a table of the objects that failed during a crawl run
+// with --keep-going.
+func printFailures(err error) {
+	var multiErr *elmercrawl.MultiError
+	if !errors.As(err, &multiErr) {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REGION\tCATALOG\tDATABASE\tTABLE\tPARTITION\tOBJECT\tERROR")
+	for _, e := range multiErr.Errors {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%v\n", e.Region, e.CatalogId, e.Database, e.Table, strings.Join(e.Partition, ","), failedObjectName(e), e.Err)
+	}
+	w.Flush()
+}
+
+// failedObjectName names the object below a database, table or partition
+// that failed, such as a table version or function.
+func failedObjectName(e *elmercrawl.ObjectError) string {
+	switch {
+	case e.Version != "":
+		return "version " + e.Version
+	case e.Function != "":
+		return "function " + e.Function
+	case e.Connection != "":
+		return "connection " + e.Connection
+	case e.Index != "":
+		return "partition index " + e.Index
+	}
+	return ""
+}
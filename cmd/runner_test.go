@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/akumor/elmercrawl/pkg/elmercrawl"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+func TestTemplateData(t *testing.T) {
+	cases := []struct {
+		Target   *target
+		Object   interface{}
+		Template string
+		Expected string
+	}{
+		{
+			Target:   &target{Region: "eu-west-1"},
+			Object:   glue.Database{Name: aws.String("db"), CatalogId: aws.String("123")},
+			Template: "{{.Region}} {{.CatalogId}} {{.Name}}",
+			Expected: "eu-west-1 123 db",
+		},
+		{
+			Target:   &target{catalogTarget: catalogTarget{CatalogId: "456"}, Region: "us-east-1"},
+			Object:   glue.TableData{Name: aws.String("t")},
+			Template: "{{.CatalogId}} {{.Name}}",
+			Expected: "456 t",
+		},
+		{
+			Target:   &target{catalogTarget: catalogTarget{CatalogId: "456"}},
+			Object:   glue.Connection{Name: aws.String("conn")},
+			Template: "{{.CatalogId}} {{.Name}}",
+			Expected: "456 conn",
+		},
+		{
+			Target:   &target{},
+			Object:   glue.Partition{Values: []*string{aws.String("2023")}},
+			Template: "{{.String}}",
+			Expected: glue.Partition{Values: []*string{aws.String("2023")}}.String(),
+		},
+		{
+			Target:   &target{Region: "eu-west-1"},
+			Object:   elmercrawl.PartitionIndex{Index: &glue.PartitionIndexDescriptor{IndexName: aws.String("idx")}},
+			Template: "{{.Region}} {{.Index.IndexName}}",
+			Expected: "eu-west-1 idx",
+		},
+	}
+
+	for i, c := range cases {
+		var buf bytes.Buffer
+		err := template.Must(template.New("test").Parse(c.Template)).Execute(&buf, templateData(c.Target, c.Object))
+		if err != nil {
+			t.Fatalf("%d, unexpected error: %v", i, err)
+		}
+		if buf.String() != c.Expected {
+			t.Fatalf("%d, expected %q, got %q", i, c.Expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/akumor/elmercrawl/pkg/elmercrawl"
+)
+
+// writeSnapshot writes s to path as JSON or NDJSON.
+func writeSnapshot(path, format string, s *elmercrawl.Snapshot) (err error) {
+	var write func(*elmercrawl.Snapshot, *os.File) error
+	switch format {
+	case "json":
+		write = func(s *elmercrawl.Snapshot, f *os.File) error { return s.WriteJSON(f) }
+	case "ndjson":
+		write = func(s *elmercrawl.Snapshot, f *os.File) error { return s.WriteNDJSON(f) }
+	default:
+		return fmt.Errorf("unknown snapshot format %q, expected json or ndjson", format)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create snapshot file: %w", err)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("unable to write snapshot file: %w", closeErr)
+		}
+	}()
+	return write(s, f)
+}
+
+// snapshotAnnotation marks the commands that can run against a snapshot file
+// given with --from-snapshot.
+const snapshotAnnotation = "snapshot"
+
+var snapshotSupported = map[string]string{snapshotAnnotation: "true"}
+
+// getSnapshotTargets returns a single target that crawls the snapshot file
+// given with --from-snapshot.
+func getSnapshotTargets(opts RootOpts) ([]*target, error) {
+	f, err := os.Open(opts.FromSnapshot)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open snapshot file: %w", err)
+	}
+	defer f.Close()
+	snapshot, err := elmercrawl.ReadSnapshot(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read snapshot file %s: %w", opts.FromSnapshot, err)
+	}
+	crawler, err := getCrawler(opts, elmercrawl.NewSnapshotGlue(snapshot), snapshot.CatalogId)
+	if err != nil {
+		return nil, err
+	}
+	crawler.Region = snapshot.Region
+	return []*target{{
+		catalogTarget: catalogTarget{CatalogId: snapshot.CatalogId},
+		Region:        snapshot.Region,
+		crawler:       crawler,
+	}}, nil
+}
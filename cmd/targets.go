@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/akumor/elmercrawl/pkg/elmercrawl"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// catalogTarget is one catalog given with --catalog, optionally reached
+// through an assumed IAM role.
+type catalogTarget struct {
+	CatalogId string
+	RoleArn   string
+}
+
+// parseCatalogTarget parses a --catalog value of the form ID or ID=ROLE_ARN.
+func parseCatalogTarget(value string) (catalogTarget, error) {
+	id, roleArn, _ := strings.Cut(value, "=")
+	if id == "" {
+		return catalogTarget{}, fmt.Errorf("invalid catalog %q: missing catalog ID", value)
+	}
+	return catalogTarget{CatalogId: id, RoleArn: roleArn}, nil
+}
+
+// target is a crawler for one catalog in one region of the run.
+type target struct {
+	catalogTarget
+	Region  string
+	crawler elmercrawl.Crawler
+	// label prefixes output for this target when a run has several.
+	label string
+	// optional targets, the regions of --all-regions, that fail as a whole
+	// are reported with the other failures instead of stopping the run, since
+	// some regions are not enabled for every account.
+	optional bool
+}
+
+// getRegions returns the regions to crawl. With --all-regions these are
+// every region that offers Glue in the partition of the first --aws-region,
+// normally aws, since credentials for one partition do not work in another.
+func getRegions(opts RootOpts) []string {
+	if !opts.AllRegions {
+		return opts.AWSRegions
+	}
+	region := "us-east-1"
+	if len(opts.AWSRegions) > 0 {
+		region = opts.AWSRegions[0]
+	}
+	p, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region)
+	if !ok {
+		return nil
+	}
+	svc, ok := p.Services()[glue.EndpointsID]
+	if !ok {
+		return nil
+	}
+	var regions []string
+	for id := range svc.Regions() {
+		regions = append(regions, id)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+func getTargets(opts RootOpts) ([]*target, error) {
+	if opts.FromSnapshot != "" {
+		return getSnapshotTargets(opts)
+	}
+	catalogs := []catalogTarget{{CatalogId: opts.CatalogId}}
+	if len(opts.Catalogs) > 0 {
+		catalogs = nil
+		for _, value := range opts.Catalogs {
+			catalog, err := parseCatalogTarget(value)
+			if err != nil {
+				return nil, err
+			}
+			catalogs = append(catalogs, catalog)
+		}
+	}
+	regions := getRegions(opts)
+	if len(regions) == 0 {
+		return nil, errors.New("no AWS region to crawl")
+	}
+	var targets []*target
+	for _, region := range regions {
+		sess, err := session.NewSession(
+			&aws.Config{
+				Region: aws.String(region),
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create AWS session for %s: %w", region, err)
+		}
+		for _, catalog := range catalogs {
+			catalogSess := sess
+			if catalog.RoleArn != "" {
+				catalogSess = sess.Copy(&aws.Config{
+					Credentials: stscreds.NewCredentials(sess, catalog.RoleArn),
+				})
+			}
+			crawler, err := getCrawler(opts, glue.New(catalogSess), catalog.CatalogId)
+			if err != nil {
+				return nil, err
+			}
+			crawler.Region = region
+			targets = append(targets, &target{catalogTarget: catalog, Region: region, crawler: crawler, optional: opts.AllRegions})
+		}
+	}
+	if len(targets) > 1 {
+		for _, t := range targets {
+			var names []string
+			if len(regions) > 1 {
+				names = append(names, t.Region)
+			}
+			if len(catalogs) > 1 {
+				names = append(names, t.CatalogId)
+			}
+			t.label = "[" + strings.Join(names, " ") + "] "
+		}
+	}
+	return targets, nil
+}
+
+// name names a target in errors.
+func (t *target) name() string {
+	if t.CatalogId == "" {
+		return t.Region
+	}
+	return t.Region + " catalog " + t.CatalogId
+}
+
+// forEachTarget runs fn for the targets of each region concurrently, and for
+// the catalogs within a region in turn. Failures reported as a
+// *elmercrawl.MultiError do not stop the run; they are merged into a single
+// *elmercrawl.MultiError returned once every target was crawled, as are
+// failures of optional targets. Any other error cancels the targets still
+// running.
+func forEachTarget(ctx context.Context, targets []*target, fn func(context.Context, *target) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var regions []string
+	byRegion := map[string][]int{}
+	for i, t := range targets {
+		if _, ok := byRegion[t.Region]; !ok {
+			regions = append(regions, t.Region)
+		}
+		byRegion[t.Region] = append(byRegion[t.Region], i)
+	}
+	results := make([]error, len(targets))
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for _, region := range regions {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+			for _, i := range indexes {
+				err := fn(ctx, targets[i])
+				results[i] = err
+				var multiErr *elmercrawl.MultiError
+				if err == nil || errors.As(err, &multiErr) {
+					continue
+				}
+				if targets[i].optional && ctx.Err() == nil {
+					results[i] = &elmercrawl.MultiError{Errors: []*elmercrawl.ObjectError{{
+						Region:    targets[i].Region,
+						CatalogId: targets[i].CatalogId,
+						Err:       err,
+					}}}
+					continue
+				}
+				if len(targets) > 1 {
+					err = fmt.Errorf("%s: %w", targets[i].name(), err)
+				}
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+		}(byRegion[region])
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	var failures []*elmercrawl.ObjectError
+	for _, err := range results {
+		var multiErr *elmercrawl.MultiError
+		if errors.As(err, &multiErr) {
+			failures = append(failures, multiErr.Errors...)
+		}
+	}
+	if len(failures) > 0 {
+		return &elmercrawl.MultiError{Errors: failures}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/akumor/elmercrawl/pkg/elmercrawl"
+)
+
+func TestGetRegions(t *testing.T) {
+	regions := getRegions(RootOpts{AWSRegions: []string{"eu-west-1"}})
+	if len(regions) != 1 || regions[0] != "eu-west-1" {
+		t.Fatalf("expected the given region, got %v", regions)
+	}
+
+	regions = getRegions(RootOpts{AWSRegions: []string{"us-east-1"}, AllRegions: true})
+	found := map[string]bool{}
+	for _, region := range regions {
+		found[region] = true
+		if strings.HasPrefix(region, "cn-") || strings.HasPrefix(region, "us-gov-") || strings.HasPrefix(region, "us-iso") {
+			t.Fatalf("expected only regions of the aws partition, got %s", region)
+		}
+	}
+	if !found["us-east-1"] || !found["eu-west-1"] {
+		t.Fatalf("expected us-east-1 and eu-west-1, got %v", regions)
+	}
+
+	regions = getRegions(RootOpts{AWSRegions: []string{"cn-north-1"}, AllRegions: true})
+	if len(regions) == 0 {
+		t.Fatalf("expected the regions of the aws-cn partition")
+	}
+	for _, region := range regions {
+		if !strings.HasPrefix(region, "cn-") {
+			t.Fatalf("expected only regions of the aws-cn partition, got %s", region)
+		}
+	}
+}
+
+func TestGetTargetsAllRegions(t *testing.T) {
+	opts := RootOpts{AWSRegions: []string{"us-east-1"}, AllRegions: true, Catalogs: []string{"111", "222"}}
+	targets, err := getTargets(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	regions := getRegions(opts)
+	if len(targets) != 2*len(regions) {
+		t.Fatalf("expected a target per region and catalog, got %d for %d regions", len(targets), len(regions))
+	}
+	for _, target := range targets {
+		if !target.optional || target.crawler.Region != target.Region || target.crawler.CatalogId != target.CatalogId {
+			t.Fatalf("unexpected target %+v", target)
+		}
+		if target.label != "["+target.Region+" "+target.CatalogId+"] " {
+			t.Fatalf("unexpected label %q", target.label)
+		}
+	}
+}
+
+func TestForEachTargetOptionalFailure(t *testing.T) {
+	targets := []*target{
+		{Region: "us-east-1", optional: true},
+		{Region: "af-south-1", optional: true},
+		{Region: "eu-west-1", optional: true},
+	}
+	var (
+		mu      sync.Mutex
+		crawled []string
+	)
+	err := forEachTarget(context.Background(), targets, func(ctx context.Context, t *target) error {
+		if t.Region == "af-south-1" {
+			return errors.New("UnrecognizedClientException")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		crawled = append(crawled, t.Region)
+		return nil
+	})
+	var multi *elmercrawl.MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 1 || multi.Errors[0].Object() != "region af-south-1" {
+		t.Fatalf("expected the failed region to be reported, got %v", err)
+	}
+	if len(crawled) != 2 {
+		t.Fatalf("expected the other regions to be crawled, got %v", crawled)
+	}
+}
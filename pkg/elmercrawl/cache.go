@@ -0,0 +1,317 @@
+package elmercrawl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// CacheLevel names one level of the objects a Crawler caches between crawls.
+type CacheLevel int
+
+const (
+	DatabaseCache CacheLevel = iota
+	TableCache
+	PartitionCache
+	// ConnectionCache holds the catalog's connections, which are not listed
+	// from any other level.
+	ConnectionCache
+)
+
+func (l CacheLevel) String() string {
+	switch l {
+	case DatabaseCache:
+		return "databases"
+	case TableCache:
+		return "tables"
+	case PartitionCache:
+		return "partitions"
+	case ConnectionCache:
+		return "connections"
+	}
+	return fmt.Sprintf("CacheLevel(%d)", int(l))
+}
+
+// Invalidate drops the cached objects of level and of every level below it,
+// so that the next crawl fetches them again. Caches listed from an
+// invalidated level, such as table versions or functions, are dropped too.
+// Connections belong to no other level and are only dropped by
+// ConnectionCache. Invalidate must not be called while a crawl is running.
+func (c *Crawler) Invalidate(level CacheLevel) {
+	switch level {
+	case DatabaseCache:
+		c.databases = nil
+	case TableCache:
+		c.tables = nil
+	case PartitionCache:
+		c.partitions = nil
+		c.stalePartitions = nil
+	case ConnectionCache:
+		c.connections = nil
+	}
+	c.dropBelow(level)
+}
+
+// InvalidateDatabase drops the cached tables and partitions of one database,
+// so that the next crawl lists them again while keeping the rest of the
+// cache.
+func (c *Crawler) InvalidateDatabase(database string) {
+	if c.tables != nil {
+		c.staleTables = markStale(c.staleTables, database)
+	}
+	if c.partitions != nil {
+		c.stalePartitions = markStale(c.stalePartitions, database)
+	}
+	c.functions = nil
+	c.tableVersions = nil
+	c.partitionIndexes = nil
+}
+
+// InvalidateTable drops the cached partitions of one table, so that the next
+// crawl lists them again while keeping the rest of the cache.
+func (c *Crawler) InvalidateTable(database, table string) {
+	if c.partitions != nil {
+		c.stalePartitions = markStale(c.stalePartitions, database+"."+table)
+	}
+	c.tableVersions = nil
+	c.partitionIndexes = nil
+}
+
+// Refresh invalidates level and fetches it again right away.
+func (c *Crawler) Refresh(ctx context.Context, level CacheLevel) error {
+	c.Invalidate(level)
+	var err error
+	switch level {
+	case DatabaseCache:
+		err = c.loadDatabases(ctx)
+	case TableCache:
+		err = c.loadTables(ctx)
+	case PartitionCache:
+		err = c.loadPartitions(ctx)
+	case ConnectionCache:
+		err = c.loadConnections(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("Refresh failed to get %s: %w", level, err)
+	}
+	return nil
+}
+
+// RefreshDatabase invalidates one database and lists its tables, and their
+// partitions, again right away for the levels that were cached.
+func (c *Crawler) RefreshDatabase(ctx context.Context, database string) error {
+	tables, partitions := c.tables != nil, c.partitions != nil
+	c.InvalidateDatabase(database)
+	var err error
+	switch {
+	case partitions:
+		err = c.loadPartitions(ctx)
+	case tables:
+		err = c.loadTables(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("RefreshDatabase failed to refresh database %s: %w", database, err)
+	}
+	return nil
+}
+
+// RefreshTable invalidates one table and lists its partitions again right
+// away when partitions were cached.
+func (c *Crawler) RefreshTable(ctx context.Context, database, table string) error {
+	partitions := c.partitions != nil
+	c.InvalidateTable(database, table)
+	if !partitions {
+		return nil
+	}
+	err := c.loadPartitions(ctx)
+	if err != nil {
+		return fmt.Errorf("RefreshTable failed to refresh table %s.%s: %w", database, table, err)
+	}
+	return nil
+}
+
+// dropBelow drops the caches that were listed from level, after level was
+// fetched again or invalidated.
+func (c *Crawler) dropBelow(level CacheLevel) {
+	switch level {
+	case DatabaseCache:
+		c.tables = nil
+		c.staleTables = nil
+		c.functions = nil
+		fallthrough
+	case TableCache:
+		c.partitions = nil
+		c.stalePartitions = nil
+		c.tableVersions = nil
+		c.partitionIndexes = nil
+	}
+}
+
+func markStale(stale map[string]bool, key string) map[string]bool {
+	if stale == nil {
+		stale = map[string]bool{}
+	}
+	stale[key] = true
+	return stale
+}
+
+// expired reports whether a level fetched at the given time has outlived
+// CacheTTL.
+func (c *Crawler) expired(fetched time.Time) bool {
+	return c.CacheTTL > 0 && time.Since(fetched) >= c.CacheTTL
+}
+
+// loadDatabases fills the database cache unless it holds unexpired results.
+func (c *Crawler) loadDatabases(ctx context.Context) error {
+	if c.databases != nil && !c.expired(c.databasesAt) {
+		return nil
+	}
+	return c.getDatabases(ctx)
+}
+
+// loadTables fills the table cache unless it holds unexpired results, and
+// lists the tables of invalidated databases again.
+func (c *Crawler) loadTables(ctx context.Context) error {
+	err := c.loadDatabases(ctx)
+	if err != nil {
+		return err
+	}
+	if c.tables == nil || c.expired(c.tablesAt) {
+		return c.getTables(ctx)
+	}
+	if len(c.staleTables) == 0 {
+		return nil
+	}
+	var databases []*glue.Database
+	for _, db := range c.databases {
+		if c.staleTables[aws.StringValue(db.Name)] {
+			databases = append(databases, db)
+		}
+	}
+	tables, err := c.listTables(ctx, databases)
+	if err != nil {
+		return fmt.Errorf("loadTables failed to get tables: %w", err)
+	}
+	c.tables = spliceChildren(c.databases, c.tables, tables,
+		func(db *glue.Database) (string, bool) {
+			name := aws.StringValue(db.Name)
+			return name, c.staleTables[name]
+		},
+		func(table *glue.TableData) string { return aws.StringValue(table.DatabaseName) },
+	)
+	c.staleTables = nil
+	return nil
+}
+
+// loadPartitions fills the partition cache unless it holds unexpired
+// results listed with the current PartitionExpression, and lists the
+// partitions of invalidated databases and tables again.
+func (c *Crawler) loadPartitions(ctx context.Context) error {
+	err := c.loadTables(ctx)
+	if err != nil {
+		return err
+	}
+	if c.partitions == nil || c.expired(c.partitionsAt) || c.partitionsExpression != c.PartitionExpression {
+		return c.getPartitions(ctx)
+	}
+	if len(c.stalePartitions) == 0 {
+		return nil
+	}
+	stale := func(table *glue.TableData) (string, bool) {
+		db := aws.StringValue(table.DatabaseName)
+		key := db + "." + aws.StringValue(table.Name)
+		return key, c.stalePartitions[db] || c.stalePartitions[key]
+	}
+	var tables []*glue.TableData
+	for _, table := range c.tables {
+		if _, ok := stale(table); ok {
+			tables = append(tables, table)
+		}
+	}
+	partitions, err := collectChildren(ctx, tables, c.Workers, c.walkPartitionPages)
+	if err != nil {
+		return fmt.Errorf("loadPartitions failed to get partitions: %w", err)
+	}
+	c.partitions = spliceChildren(c.tables, c.partitions, partitions, stale, func(partition *glue.Partition) string {
+		return aws.StringValue(partition.DatabaseName) + "." + aws.StringValue(partition.TableName)
+	})
+	c.stalePartitions = nil
+	return nil
+}
+
+// loadTableVersions fills the table version cache unless it holds unexpired
+// results. Fetching the tables again drops it, so expired tables have their
+// versions listed again too.
+func (c *Crawler) loadTableVersions(ctx context.Context) error {
+	err := c.loadTables(ctx)
+	if err != nil {
+		return err
+	}
+	if c.tableVersions != nil && !c.expired(c.tableVersionsAt) {
+		return nil
+	}
+	return c.getTableVersions(ctx)
+}
+
+// loadPartitionIndexes fills the partition index cache unless it holds
+// unexpired results listed from the cached tables.
+func (c *Crawler) loadPartitionIndexes(ctx context.Context) error {
+	err := c.loadTables(ctx)
+	if err != nil {
+		return err
+	}
+	if c.partitionIndexes != nil && !c.expired(c.partitionIndexesAt) {
+		return nil
+	}
+	return c.getPartitionIndexes(ctx)
+}
+
+// loadUserDefinedFunctions fills the function cache unless it holds
+// unexpired results listed from the cached databases.
+func (c *Crawler) loadUserDefinedFunctions(ctx context.Context) error {
+	err := c.loadDatabases(ctx)
+	if err != nil {
+		return err
+	}
+	if c.functions != nil && !c.expired(c.functionsAt) {
+		return nil
+	}
+	return c.getUserDefinedFunctions(ctx)
+}
+
+// loadConnections fills the connection cache unless it holds unexpired
+// results.
+func (c *Crawler) loadConnections(ctx context.Context) error {
+	if c.connections != nil && !c.expired(c.connectionsAt) {
+		return nil
+	}
+	return c.getConnections(ctx)
+}
+
+// spliceChildren rebuilds a cached level in parent order, taking the children
+// of stale parents from fresh and those of the other parents from cached.
+// parent returns the key of a parent and whether it is stale, and child the
+// key of the parent a child belongs to.
+func spliceChildren[P, T any](parents []P, cached, fresh []T, parent func(P) (string, bool), child func(T) string) []T {
+	group := func(children []T) map[string][]T {
+		byParent := map[string][]T{}
+		for _, c := range children {
+			byParent[child(c)] = append(byParent[child(c)], c)
+		}
+		return byParent
+	}
+	cachedBy, freshBy := group(cached), group(fresh)
+	children := make([]T, 0, len(cached))
+	for _, p := range parents {
+		key, stale := parent(p)
+		if stale {
+			children = append(children, freshBy[key]...)
+		} else {
+			children = append(children, cachedBy[key]...)
+		}
+	}
+	return children
+}
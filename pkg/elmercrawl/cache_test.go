@@ -0,0 +1,186 @@
+package elmercrawl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+func crawledTables(t *testing.T, crawler *Crawler) []string {
+	var got []string
+	err := crawler.CrawlTables(func(table *glue.TableData) error {
+		got = append(got, *table.DatabaseName+"."+*table.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return got
+}
+
+func crawledPartitions(t *testing.T, crawler *Crawler) []string {
+	var got []string
+	err := crawler.CrawlPartitions(func(p *glue.Partition) error {
+		got = append(got, *p.DatabaseName+"."+*p.TableName+"/"+*p.Values[0])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return got
+}
+
+func TestInvalidateDatabase(t *testing.T) {
+	catalog := newCountingCatalog(2, 2, 0)
+	crawler := Crawler{Glue: catalog}
+	if got := crawledTables(t, &crawler); len(got) != 4 {
+		t.Fatalf("expected 4 tables, got %v", got)
+	}
+	catalog.Tables["testdb0"] = append(catalog.Tables["testdb0"], &glue.TableData{
+		DatabaseName: aws.String("testdb0"),
+		Name:         aws.String("testtable2"),
+	})
+	if got := crawledTables(t, &crawler); len(got) != 4 {
+		t.Fatalf("expected cached 4 tables, got %v", got)
+	}
+	crawler.InvalidateDatabase("testdb0")
+	got := crawledTables(t, &crawler)
+	expected := []string{"testdb0.testtable0", "testdb0.testtable1", "testdb0.testtable2", "testdb1.testtable0", "testdb1.testtable1"}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+	if catalog.calls["GetTables testdb0"] != 3 || catalog.calls["GetTables testdb1"] != 1 {
+		t.Fatalf("expected only testdb0 to be listed again, got %v", catalog.calls)
+	}
+}
+
+func TestRefreshTable(t *testing.T) {
+	catalog := newCountingCatalog(1, 2, 2)
+	crawler := Crawler{Glue: catalog}
+	if got := crawledPartitions(t, &crawler); len(got) != 4 {
+		t.Fatalf("expected 4 partitions, got %v", got)
+	}
+	catalog.Partitions["testdb0.testtable0"] = catalog.Partitions["testdb0.testtable0"][:1]
+	err := crawler.RefreshTable(context.Background(), "testdb0", "testtable0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if catalog.calls["GetPartitions testdb0.testtable0"] != 2 || catalog.calls["GetPartitions testdb0.testtable1"] != 1 {
+		t.Fatalf("expected only testtable0 to be listed again, got %v", catalog.calls)
+	}
+	got := crawledPartitions(t, &crawler)
+	expected := []string{"testdb0.testtable0/20220900", "testdb0.testtable1/20220900", "testdb0.testtable1/20220901"}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestRefreshDatabases(t *testing.T) {
+	catalog := newMockedCatalog(2, 1, 0)
+	crawler := Crawler{Glue: &catalog}
+	if got := crawledTables(t, &crawler); len(got) != 2 {
+		t.Fatalf("expected 2 tables, got %v", got)
+	}
+	catalog.Databases = append(catalog.Databases, &glue.Database{Name: aws.String("testdb2")})
+	catalog.Tables["testdb2"] = []*glue.TableData{{DatabaseName: aws.String("testdb2"), Name: aws.String("testtable0")}}
+	err := crawler.Refresh(context.Background(), DatabaseCache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if crawler.tables != nil {
+		t.Fatalf("expected refreshing databases to drop the table cache")
+	}
+	if got := crawledTables(t, &crawler); len(got) != 3 {
+		t.Fatalf("expected 3 tables after refresh, got %v", got)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, time.Hour, time.Nanosecond} {
+		catalog := newMockedCatalog(1, 1, 0)
+		crawler := Crawler{Glue: &catalog, CacheTTL: ttl}
+		crawledTables(t, &crawler)
+		catalog.Tables["testdb0"] = append(catalog.Tables["testdb0"], &glue.TableData{
+			DatabaseName: aws.String("testdb0"),
+			Name:         aws.String("testtable1"),
+		})
+		expected := 1
+		if ttl == time.Nanosecond {
+			expected = 2
+		}
+		if got := crawledTables(t, &crawler); len(got) != expected {
+			t.Fatalf("ttl %v, expected %d tables, got %v", ttl, expected, got)
+		}
+	}
+}
+
+func TestCacheTTLListedCaches(t *testing.T) {
+	for _, ttl := range []time.Duration{0, time.Nanosecond} {
+		catalog := newMockedCatalog(1, 1, 0)
+		crawler := Crawler{Glue: mockedGetTableVersions{mockedCatalog: catalog, Versions: 1}, CacheTTL: ttl}
+		crawledVersions := func() int {
+			n := 0
+			err := crawler.CrawlTableVersions(func(*glue.TableVersion) error {
+				n++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ttl %v, unexpected error: %v", ttl, err)
+			}
+			return n
+		}
+		crawledVersions()
+		catalog.Tables["testdb0"] = append(catalog.Tables["testdb0"], &glue.TableData{
+			DatabaseName: aws.String("testdb0"),
+			Name:         aws.String("testtable1"),
+		})
+		expected := 1
+		if ttl == time.Nanosecond {
+			expected = 2
+		}
+		if got := crawledVersions(); got != expected {
+			t.Fatalf("ttl %v, expected %d table versions, got %d", ttl, expected, got)
+		}
+	}
+}
+
+func TestRefreshConnections(t *testing.T) {
+	catalog := &mockedGetConnections{Connections: 1}
+	crawler := Crawler{Glue: catalog}
+	crawledConnections := func() int {
+		n := 0
+		err := crawler.CrawlConnections(func(*glue.Connection) error {
+			n++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return n
+	}
+	crawledConnections()
+	catalog.Connections = 2
+	if got := crawledConnections(); got != 1 {
+		t.Fatalf("expected 1 cached connection, got %d", got)
+	}
+	err := crawler.Refresh(context.Background(), ConnectionCache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := crawledConnections(); got != 2 {
+		t.Fatalf("expected 2 connections after refresh, got %d", got)
+	}
+}
@@ -0,0 +1,331 @@
+package elmercrawl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// Checkpoint records how far a partition crawl got, so that a later crawl
+// with the same settings can resume where it stopped.
+type Checkpoint struct {
+	// Databases are the databases whose partitions were all processed.
+	Databases []string
+	// Tables are the database.table keys whose partitions were all processed,
+	// in databases that are not finished yet.
+	Tables []string
+	// Pending holds the tables whose partitions were partly processed, keyed
+	// by database.table.
+	Pending map[string]*TableCheckpoint `json:",omitempty"`
+}
+
+// TableCheckpoint is the position of a partly processed table.
+type TableCheckpoint struct {
+	// NextToken is the GetPartitions token of the page being processed,
+	// empty for the first page.
+	NextToken string `json:",omitempty"`
+	// Processed are the values, joined by "/", of the partitions of that
+	// page that were already processed.
+	Processed []string `json:",omitempty"`
+	// Failed is set once a partition of the table failed. The table is then
+	// listed again from its first page, and Processed holds the partitions
+	// processed on every page.
+	Failed bool `json:",omitempty"`
+}
+
+func (cp *Checkpoint) clone() *Checkpoint {
+	clone := &Checkpoint{
+		Databases: append([]string(nil), cp.Databases...),
+		Tables:    append([]string(nil), cp.Tables...),
+	}
+	if len(cp.Pending) != 0 {
+		clone.Pending = make(map[string]*TableCheckpoint, len(cp.Pending))
+		for key, table := range cp.Pending {
+			clone.Pending[key] = &TableCheckpoint{NextToken: table.NextToken, Processed: append([]string(nil), table.Processed...), Failed: table.Failed}
+		}
+	}
+	return clone
+}
+
+// checkpointRun tracks one checkpointed partition crawl and keeps its
+// Checkpoint up to date.
+type checkpointRun struct {
+	checkpoint *Checkpoint
+	save       func(*Checkpoint) error
+	interval   time.Duration
+
+	mu        sync.Mutex
+	databases map[string]bool
+	tables    map[string]bool
+	// processed holds the partitions of the unfinished tables processed on
+	// any page, so that a table with a failed partition keeps them all.
+	processed map[string]map[string]bool
+	// failed holds the databases and tables with partitions whose function
+	// failed, which are not recorded as finished so a resumed crawl retries
+	// them.
+	failed map[string]bool
+	saved  time.Time
+}
+
+func (c *Crawler) newCheckpointRun() *checkpointRun {
+	cp := c.Checkpoint
+	r := &checkpointRun{
+		checkpoint: cp,
+		save:       c.SaveCheckpoint,
+		interval:   c.CheckpointInterval,
+		databases:  map[string]bool{},
+		tables:     map[string]bool{},
+		processed:  map[string]map[string]bool{},
+		failed:     map[string]bool{},
+		saved:      time.Now(),
+	}
+	for _, db := range cp.Databases {
+		r.databases[db] = true
+	}
+	for _, table := range cp.Tables {
+		r.tables[table] = true
+	}
+	if cp.Pending == nil {
+		cp.Pending = map[string]*TableCheckpoint{}
+	}
+	for key, table := range cp.Pending {
+		r.processed[key] = map[string]bool{}
+		for _, values := range table.Processed {
+			r.processed[key][values] = true
+		}
+	}
+	return r
+}
+
+func (r *checkpointRun) databaseDone(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.databases[name]
+}
+
+func (r *checkpointRun) tableDone(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tables[key]
+}
+
+// resumeToken returns the token of the page a partly processed table
+// stopped in.
+func (r *checkpointRun) resumeToken(key string) *string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if table := r.checkpoint.Pending[key]; table != nil && table.NextToken != "" {
+		return aws.String(table.NextToken)
+	}
+	return nil
+}
+
+// startPage records that the page of a table fetched with token is being
+// processed. Partitions processed on the same page before, or on any page of
+// a failed table, are kept.
+func (r *checkpointRun) startPage(key string, token *string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failed[key] {
+		r.failTableLocked(key)
+		return
+	}
+	table := r.checkpoint.Pending[key]
+	if table != nil && (table.Failed || table.NextToken == aws.StringValue(token)) {
+		return
+	}
+	r.checkpoint.Pending[key] = &TableCheckpoint{NextToken: aws.StringValue(token)}
+	if r.processed[key] == nil {
+		r.processed[key] = map[string]bool{}
+	}
+}
+
+// finishTable records a table as finished and drops its position, unless one
+// of its partitions failed. A failed table keeps its processed partitions so
+// that a resumed crawl only retries the others.
+func (r *checkpointRun) finishTable(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failed[key] {
+		r.failTableLocked(key)
+		return r.saveLocked(false)
+	}
+	delete(r.checkpoint.Pending, key)
+	delete(r.processed, key)
+	r.tables[key] = true
+	r.checkpoint.Tables = append(r.checkpoint.Tables, key)
+	return r.saveLocked(false)
+}
+
+// failTableLocked switches the position of a table with a failed partition
+// to its first page, keeping the partitions processed on every page.
+func (r *checkpointRun) failTableLocked(key string) {
+	if table := r.checkpoint.Pending[key]; table == nil || !table.Failed {
+		r.checkpoint.Pending[key] = &TableCheckpoint{Processed: sortedKeys(r.processed[key]), Failed: true}
+	}
+}
+
+// finishDatabase records a database as finished unless one of its
+// partitions failed, replacing its finished tables.
+func (r *checkpointRun) finishDatabase(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failed[name] {
+		return r.saveLocked(false)
+	}
+	r.databases[name] = true
+	r.checkpoint.Databases = append(r.checkpoint.Databases, name)
+	tables := r.checkpoint.Tables[:0]
+	for _, key := range r.checkpoint.Tables {
+		if !strings.HasPrefix(key, name+".") {
+			tables = append(tables, key)
+		}
+	}
+	r.checkpoint.Tables = tables
+	return r.saveLocked(false)
+}
+
+// maybeSave saves the checkpoint once CheckpointInterval passed since it was
+// last saved.
+func (r *checkpointRun) maybeSave() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.saveLocked(false)
+}
+
+func (r *checkpointRun) saveLocked(force bool) error {
+	if r.save == nil || (!force && time.Since(r.saved) < r.interval) {
+		return nil
+	}
+	err := r.save(r.checkpoint.clone())
+	if err != nil {
+		return fmt.Errorf("checkpointRun failed to save checkpoint: %w", err)
+	}
+	r.saved = time.Now()
+	return nil
+}
+
+// finish saves the checkpoint once more when the crawl ends, whether or not
+// it failed, and returns err or else the error saving it.
+func (r *checkpointRun) finish(err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saveErr := r.saveLocked(true)
+	if err != nil {
+		return err
+	}
+	return saveErr
+}
+
+// partitionFunc skips the partitions already processed on the current page
+// of their table and records the ones processed successfully.
+func (r *checkpointRun) partitionFunc(tpf tablePartitionFunc) tablePartitionFunc {
+	return func(table *glue.TableData, partition *glue.Partition) error {
+		db := aws.StringValue(table.DatabaseName)
+		key := db + "." + aws.StringValue(table.Name)
+		values := strings.Join(aws.StringValueSlice(partition.Values), "/")
+		r.mu.Lock()
+		done := r.processed[key][values]
+		r.mu.Unlock()
+		if done {
+			return nil
+		}
+		err := tpf(table, partition)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err != nil {
+			r.failed[db] = true
+			r.failed[key] = true
+			return err
+		}
+		if pending := r.checkpoint.Pending[key]; pending != nil {
+			r.processed[key][values] = true
+			pending.Processed = append(pending.Processed, values)
+		}
+		return nil
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// streamCheckpointedPartitions streams partitions like streamPartitions,
+// skipping the databases, tables and partitions r records as processed and
+// listing each remaining table from the page it stopped in.
+func (c *Crawler) streamCheckpointedPartitions(ctx context.Context, r *checkpointRun, tpf tablePartitionFunc) error {
+	sem := newSemaphore(c.Parallel)
+	return c.walkDatabasePages(ctx, func(databases []*glue.Database) error {
+		for _, db := range databases {
+			name := aws.StringValue(db.Name)
+			if r.databaseDone(name) {
+				continue
+			}
+			tables, err := c.listTables(ctx, []*glue.Database{db})
+			if err != nil {
+				return err
+			}
+			err = forEach(ctx, len(tables), c.Workers, func(ctx context.Context, j int) error {
+				return c.walkCheckpointedTable(ctx, r, sem, tables[j], tpf)
+			})
+			if err != nil {
+				return err
+			}
+			err = r.finishDatabase(name)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (c *Crawler) walkCheckpointedTable(ctx context.Context, r *checkpointRun, sem chan struct{}, table *glue.TableData, tpf tablePartitionFunc) error {
+	key := aws.StringValue(table.DatabaseName) + "." + aws.StringValue(table.Name)
+	if r.tableDone(key) {
+		return nil
+	}
+	expression, ok, err := c.tableExpression(table)
+	if err != nil {
+		return err
+	}
+	c.expressionTally.add(ok)
+	if !ok {
+		return r.finishTable(key)
+	}
+	pages := c.partitionPages(table, expression, nil)
+	pages.token = r.resumeToken(key)
+	for {
+		token := pages.token
+		page, ok, err := pages.nextPage(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		r.startPage(key, token)
+		err = callEach(ctx, page, c.Parallel, sem, func(partition *glue.Partition) error {
+			return tpf(table, partition)
+		})
+		if err != nil {
+			return fmt.Errorf("walkCheckpointedTable failed to run function: %w", err)
+		}
+		err = r.maybeSave()
+		if err != nil {
+			return err
+		}
+	}
+	return r.finishTable(key)
+}
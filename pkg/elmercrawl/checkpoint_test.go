@@ -0,0 +1,98 @@
+package elmercrawl
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+func TestCrawlPartitionsCheckpointResume(t *testing.T) {
+	catalog := newMockedCatalog(2, 2, 5)
+	checkpoint := &Checkpoint{}
+	var saved []byte
+	crawler := Crawler{
+		Glue:       catalog,
+		Checkpoint: checkpoint,
+		SaveCheckpoint: func(cp *Checkpoint) (err error) {
+			saved, err = json.Marshal(cp)
+			return err
+		},
+	}
+	seen := map[string]int{}
+	crawl := func(failAt int) error {
+		return crawler.CrawlPartitions(func(p *glue.Partition) error {
+			key := *p.DatabaseName + "." + *p.TableName + "/" + *p.Values[0]
+			if len(seen) == failAt {
+				return errors.New("boom")
+			}
+			seen[key]++
+			return nil
+		})
+	}
+
+	// The 14th partition is the second of testdb1.testtable0's second page.
+	if err := crawl(13); err == nil {
+		t.Fatalf("expected error")
+	}
+	resumed := &Checkpoint{}
+	if err := json.Unmarshal(saved, resumed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pending := resumed.Pending["testdb1.testtable0"]
+	if len(resumed.Databases) != 1 || resumed.Databases[0] != "testdb0" || len(resumed.Tables) != 0 || pending == nil || pending.NextToken == "" || len(pending.Processed) != 1 {
+		t.Fatalf("unexpected checkpoint %s", saved)
+	}
+
+	crawler.Checkpoint = resumed
+	if err := crawl(-1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(seen) != 20 {
+		t.Fatalf("expected 20 partitions, got %d", len(seen))
+	}
+	for key, n := range seen {
+		if n != 1 {
+			t.Fatalf("expected %s to be processed once, got %d", key, n)
+		}
+	}
+	if len(resumed.Databases) != 2 || len(resumed.Tables) != 0 || len(resumed.Pending) != 0 {
+		t.Fatalf("expected a finished checkpoint, got %v", resumed)
+	}
+}
+
+func TestCrawlPartitionsCheckpointKeepGoing(t *testing.T) {
+	checkpoint := &Checkpoint{}
+	crawler := Crawler{Glue: newMockedCatalog(1, 2, 5), Checkpoint: checkpoint, KeepGoing: true}
+	err := crawler.CrawlPartitions(func(p *glue.Partition) error {
+		if *p.TableName == "testtable1" && *p.Values[0] == "20220901" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	var multi *MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 1 {
+		t.Fatalf("expected one failure, got %v", err)
+	}
+	if len(checkpoint.Databases) != 0 || len(checkpoint.Tables) != 1 || checkpoint.Tables[0] != "testdb0.testtable0" {
+		t.Fatalf("expected only testtable0 to be finished, got %v", checkpoint)
+	}
+
+	// The resumed crawl only retries the failed partition, although the
+	// others of testtable1 were on other pages.
+	var retried []string
+	err = crawler.CrawlPartitions(func(p *glue.Partition) error {
+		retried = append(retried, *p.TableName+"/"+*p.Values[0])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(retried) != 1 || retried[0] != "testtable1/20220901" {
+		t.Fatalf("expected only the failed partition to be retried, got %v", retried)
+	}
+	if len(checkpoint.Databases) != 1 || len(checkpoint.Tables) != 0 || len(checkpoint.Pending) != 0 {
+		t.Fatalf("expected a finished checkpoint, got %v", checkpoint)
+	}
+}
@@ -0,0 +1,136 @@
+package elmercrawl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// maxColumnStatisticsColumns is the largest number of column names the
+// GetColumnStatisticsFor* APIs accept in one request.
+const maxColumnStatisticsColumns = 100
+
+// TableColumnStatistics holds the column statistics Glue stores for a table.
+type TableColumnStatistics struct {
+	Table      *glue.TableData
+	Statistics []*glue.ColumnStatistics
+	Errors     []*glue.ColumnError
+}
+
+// PartitionColumnStatistics holds the column statistics Glue stores for a
+// partition, together with the partition's table.
+type PartitionColumnStatistics struct {
+	Table      *glue.TableData
+	Partition  *glue.Partition
+	Statistics []*glue.ColumnStatistics
+	Errors     []*glue.ColumnError
+}
+
+type glueTableColumnStatisticsFunc func(*TableColumnStatistics) error
+type gluePartitionColumnStatisticsFunc func(*PartitionColumnStatistics) error
+
+func (c *Crawler) CrawlTableColumnStatistics(gtcsf glueTableColumnStatisticsFunc) error {
+	return c.CrawlTableColumnStatisticsWithContext(context.Background(), gtcsf)
+}
+
+func (c *Crawler) CrawlTableColumnStatisticsWithContext(ctx context.Context, gtcsf glueTableColumnStatisticsFunc) error {
+	return c.CrawlTablesWithContext(ctx, func(table *glue.TableData) error {
+		stats, err := c.getTableColumnStatistics(ctx, table)
+		if err != nil {
+			return err
+		}
+		return gtcsf(stats)
+	})
+}
+
+func (c *Crawler) CrawlPartitionColumnStatistics(gpcsf gluePartitionColumnStatisticsFunc) error {
+	return c.CrawlPartitionColumnStatisticsWithContext(context.Background(), gpcsf)
+}
+
+func (c *Crawler) CrawlPartitionColumnStatisticsWithContext(ctx context.Context, gpcsf gluePartitionColumnStatisticsFunc) error {
+	return c.crawlPartitions(ctx, func(table *glue.TableData, partition *glue.Partition) error {
+		stats, err := c.getPartitionColumnStatistics(ctx, table, partition)
+		if err != nil {
+			return err
+		}
+		return gpcsf(stats)
+	})
+}
+
+func (c *Crawler) getTableColumnStatistics(ctx context.Context, table *glue.TableData) (*TableColumnStatistics, error) {
+	stats := &TableColumnStatistics{Table: table}
+	var columns []*glue.Column
+	if table.StorageDescriptor != nil {
+		columns = table.StorageDescriptor.Columns
+	}
+	for _, batch := range columnNameBatches(columns) {
+		input := &glue.GetColumnStatisticsForTableInput{
+			CatalogId:    c.glueCatalogId(),
+			DatabaseName: table.DatabaseName,
+			TableName:    table.Name,
+			ColumnNames:  batch,
+		}
+		var getStatsOut *glue.GetColumnStatisticsForTableOutput
+		err := c.call(ctx, "GetColumnStatisticsForTable", func() (err error) {
+			getStatsOut, err = c.Glue.GetColumnStatisticsForTableWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getTableColumnStatistics failed to get column statistics for table %s.%s: %w", aws.StringValue(table.DatabaseName), aws.StringValue(table.Name), err)
+		}
+		stats.Statistics = append(stats.Statistics, getStatsOut.ColumnStatisticsList...)
+		stats.Errors = append(stats.Errors, getStatsOut.Errors...)
+	}
+	return stats, nil
+}
+
+func (c *Crawler) getPartitionColumnStatistics(ctx context.Context, table *glue.TableData, partition *glue.Partition) (*PartitionColumnStatistics, error) {
+	stats := &PartitionColumnStatistics{Table: table, Partition: partition}
+	var columns []*glue.Column
+	if partition.StorageDescriptor != nil {
+		columns = partition.StorageDescriptor.Columns
+	}
+	if len(columns) == 0 && table != nil && table.StorageDescriptor != nil {
+		columns = table.StorageDescriptor.Columns
+	}
+	for _, batch := range columnNameBatches(columns) {
+		input := &glue.GetColumnStatisticsForPartitionInput{
+			CatalogId:       c.glueCatalogId(),
+			DatabaseName:    partition.DatabaseName,
+			TableName:       partition.TableName,
+			PartitionValues: partition.Values,
+			ColumnNames:     batch,
+		}
+		var getStatsOut *glue.GetColumnStatisticsForPartitionOutput
+		err := c.call(ctx, "GetColumnStatisticsForPartition", func() (err error) {
+			getStatsOut, err = c.Glue.GetColumnStatisticsForPartitionWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getPartitionColumnStatistics failed to get column statistics for partition %v of table %s.%s: %w", aws.StringValueSlice(partition.Values), aws.StringValue(partition.DatabaseName), aws.StringValue(partition.TableName), err)
+		}
+		stats.Statistics = append(stats.Statistics, getStatsOut.ColumnStatisticsList...)
+		stats.Errors = append(stats.Errors, getStatsOut.Errors...)
+	}
+	return stats, nil
+}
+
+// columnNameBatches splits the names of columns into batches small enough
+// for one column statistics request.
+func columnNameBatches(columns []*glue.Column) [][]*string {
+	var batches [][]*string
+	for start := 0; start < len(columns); start += maxColumnStatisticsColumns {
+		end := start + maxColumnStatisticsColumns
+		if end > len(columns) {
+			end = len(columns)
+		}
+		batch := make([]*string, 0, end-start)
+		for _, column := range columns[start:end] {
+			batch = append(batch, column.Name)
+		}
+		batches = append(batches, batch)
+	}
+	return batches
+}
@@ -0,0 +1,100 @@
+package elmercrawl
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type mockedGetColumnStatistics struct {
+	mockedCatalog
+	mu      *sync.Mutex
+	batches *[]int
+}
+
+func (m mockedGetColumnStatistics) statistics(names []*string) []*glue.ColumnStatistics {
+	m.mu.Lock()
+	*m.batches = append(*m.batches, len(names))
+	m.mu.Unlock()
+	stats := make([]*glue.ColumnStatistics, len(names))
+	for i := range names {
+		stats[i] = &glue.ColumnStatistics{ColumnName: names[i]}
+	}
+	return stats
+}
+
+func (m mockedGetColumnStatistics) GetColumnStatisticsForTableWithContext(_ aws.Context, in *glue.GetColumnStatisticsForTableInput, _ ...request.Option) (*glue.GetColumnStatisticsForTableOutput, error) {
+	return &glue.GetColumnStatisticsForTableOutput{ColumnStatisticsList: m.statistics(in.ColumnNames)}, nil
+}
+
+func (m mockedGetColumnStatistics) GetColumnStatisticsForPartitionWithContext(_ aws.Context, in *glue.GetColumnStatisticsForPartitionInput, _ ...request.Option) (*glue.GetColumnStatisticsForPartitionOutput, error) {
+	return &glue.GetColumnStatisticsForPartitionOutput{ColumnStatisticsList: m.statistics(in.ColumnNames)}, nil
+}
+
+func newMockedColumnStatistics(columns int) (mockedGetColumnStatistics, *[]int) {
+	catalog := newMockedCatalog(1, 2, 2)
+	sd := &glue.StorageDescriptor{}
+	for i := 0; i < columns; i++ {
+		sd.Columns = append(sd.Columns, &glue.Column{Name: aws.String(fmt.Sprintf("col%d", i))})
+	}
+	for _, table := range catalog.Tables["testdb0"] {
+		table.StorageDescriptor = sd
+	}
+	batches := []int{}
+	return mockedGetColumnStatistics{mockedCatalog: catalog, mu: &sync.Mutex{}, batches: &batches}, &batches
+}
+
+func TestCrawlTableColumnStatistics(t *testing.T) {
+	mock, batches := newMockedColumnStatistics(150)
+	crawler := Crawler{Glue: mock}
+	var got []*TableColumnStatistics
+	err := crawler.CrawlTableColumnStatistics(func(s *TableColumnStatistics) error {
+		got = append(got, s)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected statistics for 2 tables, got %d", len(got))
+	}
+	for _, s := range got {
+		if s.Table == nil || len(s.Statistics) != 150 {
+			t.Fatalf("expected 150 column statistics with their table, got %d", len(s.Statistics))
+		}
+	}
+	if len(*batches) != 4 || (*batches)[0] != 100 || (*batches)[1] != 50 {
+		t.Fatalf("expected batches of 100 and 50 columns per table, got %v", *batches)
+	}
+}
+
+func TestCrawlPartitionColumnStatistics(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		mock, batches := newMockedColumnStatistics(3)
+		crawler := Crawler{Glue: mock, Stream: stream, Parallel: 2}
+		var mu sync.Mutex
+		got := 0
+		err := crawler.CrawlPartitionColumnStatistics(func(s *PartitionColumnStatistics) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if s.Table == nil || *s.Table.Name != *s.Partition.TableName {
+				t.Errorf("stream %t, expected partition statistics with their table", stream)
+			}
+			if len(s.Statistics) != 3 {
+				t.Errorf("stream %t, expected 3 column statistics, got %d", stream, len(s.Statistics))
+			}
+			got++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("stream %t, unexpected error: %v", stream, err)
+		}
+		if got != 4 || len(*batches) != 4 {
+			t.Fatalf("stream %t, expected statistics for 4 partitions in 4 requests, got %d in %d", stream, got, len(*batches))
+		}
+	}
+}
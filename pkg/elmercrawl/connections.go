@@ -0,0 +1,84 @@
+package elmercrawl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type glueConnectionFunc func(*glue.Connection) error
+
+func (c *Crawler) CrawlConnections(gcf glueConnectionFunc) error {
+	return c.CrawlConnectionsWithContext(context.Background(), gcf)
+}
+
+func (c *Crawler) CrawlConnectionsWithContext(ctx context.Context, gcf glueConnectionFunc) error {
+	var failures *errorCollector
+	if c.KeepGoing {
+		failures = c.newErrorCollector()
+		gcf = failures.connectionFunc(gcf)
+	}
+	if c.Stream {
+		err := c.walkConnectionPages(ctx, func(page []*glue.Connection) error {
+			err := callEach(ctx, page, c.Parallel, nil, gcf)
+			if err != nil {
+				return fmt.Errorf("CrawlConnections failed to run function: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("CrawlConnections failed to stream connections: %w", err)
+		}
+		return failures.err()
+	}
+	err := c.loadConnections(ctx)
+	if err != nil {
+		return fmt.Errorf("CrawlConnections failed to get connections: %w", err)
+	}
+	err = callEach(ctx, c.connections, c.Parallel, nil, gcf)
+	if err != nil {
+		return fmt.Errorf("CrawlConnections failed to run function: %w", err)
+	}
+	return failures.err()
+}
+
+func (c *Crawler) getConnections(ctx context.Context) error {
+	var connections []*glue.Connection
+	err := c.walkConnectionPages(ctx, func(page []*glue.Connection) error {
+		connections = append(connections, page...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("getConnections failed to get connections: %w", err)
+	}
+	c.connections = connections
+	c.connectionsAt = time.Now()
+	return nil
+}
+
+func (c *Crawler) walkConnectionPages(ctx context.Context, pagef func([]*glue.Connection) error) error {
+	return newIterator(ctx, c.connectionPages().nextPage).eachPage(pagef)
+}
+
+// connectionPages pages through the connections of the catalog.
+func (c *Crawler) connectionPages() *pager[*glue.Connection] {
+	return &pager[*glue.Connection]{fetch: func(ctx context.Context, token *string) ([]*glue.Connection, *string, error) {
+		input := &glue.GetConnectionsInput{
+			CatalogId:    c.glueCatalogId(),
+			HidePassword: aws.Bool(!c.ShowConnectionPasswords),
+			NextToken:    token,
+		}
+		var getConnOut *glue.GetConnectionsOutput
+		err := c.call(ctx, "GetConnections", func() (err error) {
+			getConnOut, err = c.Glue.GetConnectionsWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("connectionPages failed to get connections: %w", err)
+		}
+		return getConnOut.ConnectionList, getConnOut.NextToken, nil
+	}}
+}
@@ -0,0 +1,65 @@
+package elmercrawl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/glue/glueiface"
+)
+
+type mockedGetConnections struct {
+	glueiface.GlueAPI
+	Connections int
+}
+
+func (m mockedGetConnections) GetConnectionsWithContext(_ aws.Context, in *glue.GetConnectionsInput, _ ...request.Option) (*glue.GetConnectionsOutput, error) {
+	var connections []*glue.Connection
+	for i := 0; i < m.Connections; i++ {
+		properties := map[string]*string{"USERNAME": aws.String("admin")}
+		if !aws.BoolValue(in.HidePassword) {
+			properties["PASSWORD"] = aws.String("secret")
+		}
+		connections = append(connections, &glue.Connection{
+			Name:                 aws.String(fmt.Sprintf("conn%d", i)),
+			ConnectionProperties: properties,
+		})
+	}
+	start, end, next := mockedCatalog{PageSize: 2}.page(in.NextToken, len(connections))
+	return &glue.GetConnectionsOutput{ConnectionList: connections[start:end], NextToken: next}, nil
+}
+
+func TestCrawlConnections(t *testing.T) {
+	cases := []struct {
+		Stream        bool
+		ShowPasswords bool
+	}{
+		{Stream: false, ShowPasswords: false},
+		{Stream: true, ShowPasswords: false},
+		{Stream: false, ShowPasswords: true},
+	}
+
+	for i, c := range cases {
+		crawler := Crawler{
+			Glue:                    mockedGetConnections{Connections: 5},
+			Stream:                  c.Stream,
+			ShowConnectionPasswords: c.ShowPasswords,
+		}
+		var got []string
+		err := crawler.CrawlConnections(func(conn *glue.Connection) error {
+			got = append(got, *conn.Name)
+			if _, ok := conn.ConnectionProperties["PASSWORD"]; ok != c.ShowPasswords {
+				t.Fatalf("%d, expected password shown %t for %s", i, c.ShowPasswords, *conn.Name)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%d, unexpected error: %v", i, err)
+		}
+		if len(got) != 5 || got[0] != "conn0" || got[4] != "conn4" {
+			t.Fatalf("%d, expected conn0 to conn4, got %v", i, got)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package elmercrawl
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/glue"
@@ -10,162 +13,516 @@ import (
 )
 
 type Crawler struct {
-	Glue       glueiface.GlueAPI
-	CatalogId  string
+	Glue      glueiface.GlueAPI
+	CatalogId string
+	// Region names the AWS region Glue is called in. It is only used to
+	// label failures and is not sent to Glue.
+	Region string
+	// Stream passes each page of results to the crawl function as soon as it
+	// arrives instead of caching the whole level first.
+	Stream bool
+	// Workers is the number of databases listed for tables, or tables listed
+	// for partitions, in parallel. Cached results keep catalog order; streamed
+	// pages from different databases or tables arrive in completion order.
+	Workers int
+	// Parallel is the number of crawl functions allowed to run at once.
+	Parallel int
+	// RateLimiter, when set, paces every Glue request the crawler sends.
+	RateLimiter *RateLimiter
+	// Retry, when set, retries failed page requests instead of abandoning
+	// the crawl on the first error.
+	Retry *RetryPolicy
+	// KeepGoing runs the crawl function for every object even after it
+	// failed for some, and returns the failures together as a *MultiError.
+	KeepGoing bool
+	// DatabaseFilter and TableFilter limit the crawl to matching names.
+	// Databases that are filtered out are never listed for tables, and
+	// tables that are filtered out are never listed for partitions.
+	DatabaseFilter *NameFilter
+	TableFilter    *NameFilter
+	// PartitionExpression is passed to GetPartitions as its Expression so
+	// that Glue only returns matching partitions. Tables without every
+	// partition key it refers to are skipped, since none of their partitions
+	// could match, and a crawl fails when every table it lists is skipped.
+	PartitionExpression string
+	// AllowDeleteAll lets DeletePartitions run without a PartitionExpression,
+	// deleting every partition that passes the filters.
+	AllowDeleteAll bool
+	// PartitionSegments splits the partition listing of large tables into
+	// this many segments, at most 10, that are fetched in parallel. Tables
+	// count as large once they return more than SegmentThreshold partitions;
+	// with no threshold every table is segmented.
+	PartitionSegments int
+	SegmentThreshold  int
+	// ShowConnectionPasswords includes connection passwords in crawled
+	// connections. They are hidden by default.
+	ShowConnectionPasswords bool
+	// CacheTTL, when set, fetches cached objects again once they are older
+	// than this. Fetching a level again also drops the levels below it and
+	// the objects listed from it, such as table versions or functions.
+	CacheTTL time.Duration
+	// Incremental, when set, limits CrawlTables and CrawlPartitions to the
+	// objects created, updated or accessed since the run that last updated
+	// it, and updates it after every successful crawl.
+	Incremental *IncrementalState
+	// Checkpoint, when set, records how far CrawlPartitions got, and a crawl
+	// given the checkpoint of an earlier one resumes where it stopped,
+	// skipping the databases, tables and partitions it records as processed.
+	// Checkpointed crawls stream partitions from unsegmented table listings
+	// and cannot be incremental.
+	Checkpoint *Checkpoint
+	// SaveCheckpoint, when set, is called with a copy of Checkpoint at most
+	// every CheckpointInterval while partitions are crawled, and once more
+	// when the crawl ends.
+	SaveCheckpoint     func(*Checkpoint) error
+	CheckpointInterval time.Duration
+
 	databases  []*glue.Database
 	tables     []*glue.TableData
 	partitions []*glue.Partition
+
+	databasesAt  time.Time
+	tablesAt     time.Time
+	partitionsAt time.Time
+	// partitionsExpression is the PartitionExpression the partition cache
+	// was listed with.
+	partitionsExpression string
+	// expressionTally, set while CrawlPartitions streams partitions, counts
+	// the tables the expression was checked against.
+	expressionTally *expressionTally
+	// staleTables and stalePartitions hold the databases, and "db.table"
+	// keys, whose children were invalidated in an otherwise cached level.
+	staleTables     map[string]bool
+	stalePartitions map[string]bool
+
+	tableVersions []*glue.TableVersion
+	functions     []*glue.UserDefinedFunction
+
+	connections      []*glue.Connection
+	partitionIndexes []*PartitionIndex
+
+	tableVersionsAt    time.Time
+	functionsAt        time.Time
+	connectionsAt      time.Time
+	partitionIndexesAt time.Time
+}
+
+// glueCatalogId returns the catalog ID to send with Glue requests, leaving it
+// unset for the caller's own catalog.
+func (c *Crawler) glueCatalogId() *string {
+	if c.CatalogId == "" {
+		return nil
+	}
+	return aws.String(c.CatalogId)
 }
 
 type glueDBFunc func(*glue.Database) error
 type glueTableFunc func(*glue.TableData) error
 type gluePartitionFunc func(*glue.Partition) error
+type tablePartitionFunc func(*glue.TableData, *glue.Partition) error
 
 func (c *Crawler) CrawlDatabases(gdbf glueDBFunc) error {
-	if c.databases == nil {
-		err := c.getDatabases()
-		if err != nil {
-			return fmt.Errorf("CrawlDatabases failed to get databases: %w", err)
-		}
+	return c.CrawlDatabasesWithContext(context.Background(), gdbf)
+}
+
+func (c *Crawler) CrawlDatabasesWithContext(ctx context.Context, gdbf glueDBFunc) error {
+	var failures *errorCollector
+	if c.KeepGoing {
+		failures = c.newErrorCollector()
+		gdbf = failures.databaseFunc(gdbf)
 	}
-	for i := range c.databases {
-		err := gdbf(c.databases[i])
+	if c.Stream {
+		err := c.streamDatabases(ctx, gdbf)
 		if err != nil {
-			return fmt.Errorf("CrawlDatabases failed to run function: %w", err)
+			return fmt.Errorf("CrawlDatabases failed to stream databases: %w", err)
 		}
+		return failures.err()
 	}
-	return nil
+	err := c.loadDatabases(ctx)
+	if err != nil {
+		return fmt.Errorf("CrawlDatabases failed to get databases: %w", err)
+	}
+	err = callEach(ctx, c.databases, c.Parallel, nil, gdbf)
+	if err != nil {
+		return fmt.Errorf("CrawlDatabases failed to run function: %w", err)
+	}
+	return failures.err()
 }
 
-func (c *Crawler) getDatabases() error {
-	getDbOut, err := c.Glue.GetDatabases(&glue.GetDatabasesInput{})
+func (c *Crawler) getDatabases(ctx context.Context) error {
+	var databases []*glue.Database
+	err := c.walkDatabasePages(ctx, func(page []*glue.Database) error {
+		databases = append(databases, page...)
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("getDatabases failed to get databases: %w", err)
 	}
-	c.databases = getDbOut.DatabaseList
-	for {
-		if getDbOut.NextToken == nil {
-			break
+	c.databases = databases
+	c.databasesAt = time.Now()
+	c.dropBelow(DatabaseCache)
+	return nil
+}
+
+func (c *Crawler) streamDatabases(ctx context.Context, gdbf glueDBFunc) error {
+	return c.walkDatabasePages(ctx, func(page []*glue.Database) error {
+		err := callEach(ctx, page, c.Parallel, nil, gdbf)
+		if err != nil {
+			return fmt.Errorf("streamDatabases failed to run function: %w", err)
 		}
-		getDbOut, err = c.Glue.GetDatabases(&glue.GetDatabasesInput{
-			NextToken: getDbOut.NextToken,
+		return nil
+	})
+}
+
+func (c *Crawler) walkDatabasePages(ctx context.Context, pagef func([]*glue.Database) error) error {
+	return c.Databases(ctx).eachPage(pagef)
+}
+
+// databasePages pages through the databases that pass DatabaseFilter.
+func (c *Crawler) databasePages() *pager[*glue.Database] {
+	return &pager[*glue.Database]{fetch: func(ctx context.Context, token *string) ([]*glue.Database, *string, error) {
+		input := &glue.GetDatabasesInput{
+			CatalogId: c.glueCatalogId(),
+			NextToken: token,
+		}
+		var getDbOut *glue.GetDatabasesOutput
+		err := c.call(ctx, "GetDatabases", func() (err error) {
+			getDbOut, err = c.Glue.GetDatabasesWithContext(ctx, input)
+			return err
 		})
 		if err != nil {
-			return fmt.Errorf("getDatabases failed to get databases with token: %w", err)
+			return nil, nil, fmt.Errorf("databasePages failed to get databases: %w", err)
 		}
-		c.databases = append(c.databases, getDbOut.DatabaseList...)
-	}
-	return nil
+		return filterNames(c.DatabaseFilter, getDbOut.DatabaseList, func(db *glue.Database) *string { return db.Name }), getDbOut.NextToken, nil
+	}}
 }
 
 func (c *Crawler) CrawlTables(gtf glueTableFunc) error {
-	if c.tables == nil {
-		err := c.getTables()
-		if err != nil {
-			return fmt.Errorf("CrawlTables failed to get tables: %w", err)
-		}
+	return c.CrawlTablesWithContext(context.Background(), gtf)
+}
+
+func (c *Crawler) CrawlTablesWithContext(ctx context.Context, gtf glueTableFunc) error {
+	var failures *errorCollector
+	if c.KeepGoing {
+		failures = c.newErrorCollector()
+		gtf = failures.tableFunc(gtf)
 	}
-	for i := range c.tables {
-		err := gtf(c.tables[i])
+	var incremental *incrementalRun
+	if c.Incremental != nil {
+		incremental = newIncrementalRun(&c.Incremental.Tables)
+		gtf = incremental.tableFunc(gtf)
+	}
+	if c.Stream {
+		err := c.streamTables(ctx, gtf)
 		if err != nil {
-			return fmt.Errorf("CrawlTables failed to run function: %w", err)
+			return fmt.Errorf("CrawlTables failed to stream tables: %w", err)
 		}
+		return incremental.finish(failures.err())
+	}
+	err := c.loadTables(ctx)
+	if err != nil {
+		return fmt.Errorf("CrawlTables failed to get tables: %w", err)
+	}
+	incremental.listedAt(c.tablesAt)
+	err = callEach(ctx, c.tables, c.Parallel, nil, gtf)
+	if err != nil {
+		return fmt.Errorf("CrawlTables failed to run function: %w", err)
+	}
+	return incremental.finish(failures.err())
+}
+
+func (c *Crawler) getTables(ctx context.Context) error {
+	err := c.loadDatabases(ctx)
+	if err != nil {
+		return fmt.Errorf("getTables failed to get databases: %w", err)
 	}
+	tables, err := c.listTables(ctx, c.databases)
+	if err != nil {
+		return fmt.Errorf("getTables failed to get tables: %w", err)
+	}
+	c.tables = tables
+	c.tablesAt = time.Now()
+	c.dropBelow(TableCache)
 	return nil
 }
 
-func (c *Crawler) getTables() error {
-	if c.databases == nil {
-		err := c.getDatabases()
-		if err != nil {
-			return fmt.Errorf("getTables failed to get databases: %w", err)
+func (c *Crawler) listTables(ctx context.Context, databases []*glue.Database) ([]*glue.TableData, error) {
+	return collectChildren(ctx, databases, c.Workers, c.walkTablePages)
+}
+
+func (c *Crawler) streamTables(ctx context.Context, gtf glueTableFunc) error {
+	sem := newSemaphore(c.Parallel)
+	return c.streamEachDatabase(ctx, func(ctx context.Context, db *glue.Database) error {
+		return c.walkTablePages(ctx, db, func(page []*glue.TableData) error {
+			err := callEach(ctx, page, c.Parallel, sem, gtf)
+			if err != nil {
+				return fmt.Errorf("streamTables failed to run function: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// streamEachDatabase pages through databases and calls walk for the
+// databases of each page on up to Workers goroutines.
+func (c *Crawler) streamEachDatabase(ctx context.Context, walk func(context.Context, *glue.Database) error) error {
+	return c.walkDatabasePages(ctx, func(databases []*glue.Database) error {
+		return forEach(ctx, len(databases), c.Workers, func(ctx context.Context, i int) error {
+			return walk(ctx, databases[i])
+		})
+	})
+}
+
+func (c *Crawler) walkTablePages(ctx context.Context, db *glue.Database, pagef func([]*glue.TableData) error) error {
+	return newIterator(ctx, c.tablePages(db).nextPage).eachPage(pagef)
+}
+
+// tablePages pages through the tables of db that pass TableFilter.
+func (c *Crawler) tablePages(db *glue.Database) *pager[*glue.TableData] {
+	return &pager[*glue.TableData]{fetch: func(ctx context.Context, token *string) ([]*glue.TableData, *string, error) {
+		input := &glue.GetTablesInput{
+			CatalogId:    c.glueCatalogId(),
+			DatabaseName: db.Name,
+			NextToken:    token,
 		}
-	}
-	for i := range c.databases {
-		getTblOut, err := c.Glue.GetTables(&glue.GetTablesInput{
-			DatabaseName: c.databases[i].Name,
+		var getTblOut *glue.GetTablesOutput
+		err := c.call(ctx, "GetTables", func() (err error) {
+			getTblOut, err = c.Glue.GetTablesWithContext(ctx, input)
+			return err
 		})
 		if err != nil {
-			return fmt.Errorf("getTables failed to get tables: %w", err)
-		}
-		if c.tables == nil {
-			c.tables = getTblOut.TableList
-		} else {
-			c.tables = append(c.tables, getTblOut.TableList...)
-		}
-		for {
-			if getTblOut.NextToken == nil {
-				break
-			}
-			getTblOut, err = c.Glue.GetTables(&glue.GetTablesInput{
-				DatabaseName: c.databases[i].Name,
-				NextToken:    getTblOut.NextToken,
-			})
-			if err != nil {
-				return fmt.Errorf("getTables failed to get tables with token: %w", err)
-			}
-			c.tables = append(c.tables, getTblOut.TableList...)
+			return nil, nil, fmt.Errorf("tablePages failed to get tables for database %s: %w", aws.StringValue(db.Name), err)
 		}
-	}
-	return nil
+		return filterNames(c.TableFilter, getTblOut.TableList, func(table *glue.TableData) *string { return table.Name }), getTblOut.NextToken, nil
+	}}
 }
 
 func (c *Crawler) CrawlPartitions(gpf gluePartitionFunc) error {
-	if c.partitions == nil {
-		err := c.getPartitions()
+	return c.CrawlPartitionsWithContext(context.Background(), gpf)
+}
+
+func (c *Crawler) CrawlPartitionsWithContext(ctx context.Context, gpf gluePartitionFunc) error {
+	return c.crawlPartitions(ctx, func(_ *glue.TableData, partition *glue.Partition) error {
+		return gpf(partition)
+	})
+}
+
+// crawlPartitions crawls partitions like CrawlPartitions but also hands the
+// function each partition's table.
+func (c *Crawler) crawlPartitions(ctx context.Context, tpf tablePartitionFunc) error {
+	if c.PartitionExpression != "" {
+		err := checkPartitionExpression(c.PartitionExpression, nil)
 		if err != nil {
-			return fmt.Errorf("CrawlPartitions failed to get partitions: %w", err)
+			return fmt.Errorf("crawlPartitions failed to parse expression: %w", err)
+		}
+	}
+	var tally *expressionTally
+	if c.PartitionExpression != "" {
+		tally = &expressionTally{}
+		c.expressionTally = tally
+		defer func() { c.expressionTally = nil }()
+	}
+	var checkpoint *checkpointRun
+	if c.Checkpoint != nil {
+		if c.Incremental != nil {
+			return errors.New("crawlPartitions cannot resume an incremental crawl from a checkpoint")
 		}
+		checkpoint = c.newCheckpointRun()
+		tpf = checkpoint.partitionFunc(tpf)
 	}
-	for i := range c.partitions {
-		err := gpf(c.partitions[i])
+	var failures *errorCollector
+	if c.KeepGoing {
+		failures = c.newErrorCollector()
+		tpf = failures.partitionFunc(tpf)
+	}
+	var incremental *incrementalRun
+	if c.Incremental != nil {
+		incremental = newIncrementalRun(&c.Incremental.Partitions)
+		tpf = incremental.partitionFunc(tpf)
+	}
+	// done reports an expression no table could match before the failures
+	// kept going past.
+	done := func() error {
+		err := tally.err(c.PartitionExpression)
 		if err != nil {
-			return fmt.Errorf("CrawlPartitions failed to run function: %w", err)
+			return fmt.Errorf("crawlPartitions failed to match expression: %w", err)
 		}
+		return failures.err()
 	}
-	return nil
-}
-
-func (c *Crawler) getPartitions() error {
-	if c.tables == nil {
-		err := c.getTables()
+	if checkpoint != nil {
+		err := checkpoint.finish(c.streamCheckpointedPartitions(ctx, checkpoint, tpf))
 		if err != nil {
-			return fmt.Errorf("getPartitions failed to get tables: %w", err)
+			return fmt.Errorf("crawlPartitions failed to stream partitions from checkpoint: %w", err)
 		}
+		return done()
 	}
-	for i := range c.tables {
-		getPartOut, err := c.Glue.GetPartitions(&glue.GetPartitionsInput{
-			DatabaseName: c.tables[i].DatabaseName,
-			TableName:    c.tables[i].Name,
-		})
+	if c.Stream {
+		err := c.streamPartitions(ctx, tpf)
 		if err != nil {
-			return fmt.Errorf("getPartitions failed to get partitions: %w", err)
+			return fmt.Errorf("crawlPartitions failed to stream partitions: %w", err)
 		}
-		if c.partitions == nil {
-			c.partitions = getPartOut.Partitions
-		} else {
-			c.partitions = append(c.partitions, getPartOut.Partitions...)
+		return incremental.finish(done())
+	}
+	err := c.loadPartitions(ctx)
+	if err != nil {
+		return fmt.Errorf("crawlPartitions failed to get partitions: %w", err)
+	}
+	incremental.listedAt(c.partitionsAt)
+	if tally != nil {
+		// The partitions may come from the cache, so the cached tables are
+		// checked instead of the ones listed.
+		tally = &expressionTally{}
+		for _, table := range c.tables {
+			tally.add(ValidatePartitionExpression(c.PartitionExpression, table.PartitionKeys) == nil)
 		}
-		for {
-			if getPartOut.NextToken == nil {
-				break
+	}
+	tables := make(map[string]*glue.TableData, len(c.tables))
+	for _, table := range c.tables {
+		tables[aws.StringValue(table.DatabaseName)+"."+aws.StringValue(table.Name)] = table
+	}
+	err = callEach(ctx, c.partitions, c.Parallel, nil, func(partition *glue.Partition) error {
+		return tpf(tables[aws.StringValue(partition.DatabaseName)+"."+aws.StringValue(partition.TableName)], partition)
+	})
+	if err != nil {
+		return fmt.Errorf("crawlPartitions failed to run function: %w", err)
+	}
+	return incremental.finish(done())
+}
+
+func (c *Crawler) getPartitions(ctx context.Context) error {
+	err := c.loadTables(ctx)
+	if err != nil {
+		return fmt.Errorf("getPartitions failed to get tables: %w", err)
+	}
+	partitions, err := collectChildren(ctx, c.tables, c.Workers, c.walkPartitionPages)
+	if err != nil {
+		return fmt.Errorf("getPartitions failed to get partitions: %w", err)
+	}
+	c.partitions = partitions
+	c.partitionsAt = time.Now()
+	c.partitionsExpression = c.PartitionExpression
+	c.stalePartitions = nil
+	return nil
+}
+
+func (c *Crawler) streamPartitions(ctx context.Context, tpf tablePartitionFunc) error {
+	sem := newSemaphore(c.Parallel)
+	return c.streamEachTable(ctx, func(ctx context.Context, table *glue.TableData) error {
+		return c.walkPartitionPages(ctx, table, func(page []*glue.Partition) error {
+			err := callEach(ctx, page, c.Parallel, sem, func(partition *glue.Partition) error {
+				return tpf(table, partition)
+			})
+			if err != nil {
+				return fmt.Errorf("streamPartitions failed to run function: %w", err)
 			}
-			getPartOut, err = c.Glue.GetPartitions(&glue.GetPartitionsInput{
-				DatabaseName: c.tables[i].DatabaseName,
-				TableName:    c.tables[i].Name,
-				NextToken:    getPartOut.NextToken,
+			return nil
+		})
+	})
+}
+
+// streamEachTable lists tables one database at a time and calls walk for
+// each of them on up to Workers goroutines.
+func (c *Crawler) streamEachTable(ctx context.Context, walk func(context.Context, *glue.TableData) error) error {
+	return c.walkDatabasePages(ctx, func(databases []*glue.Database) error {
+		for i := range databases {
+			tables, err := c.listTables(ctx, databases[i:i+1])
+			if err != nil {
+				return err
+			}
+			err = forEach(ctx, len(tables), c.Workers, func(ctx context.Context, j int) error {
+				return walk(ctx, tables[j])
 			})
 			if err != nil {
-				return fmt.Errorf("getPartitions failed to get partitions with token: %w", err)
+				return err
 			}
-			c.partitions = append(c.partitions, getPartOut.Partitions...)
 		}
+		return nil
+	})
+}
+
+// collectChildren pages through the objects below each parent on up to
+// workers goroutines and returns them in parent order.
+func collectChildren[P, T any](ctx context.Context, parents []P, workers int, walk func(context.Context, P, func([]T) error) error) ([]T, error) {
+	results := make([][]T, len(parents))
+	err := forEach(ctx, len(parents), workers, func(ctx context.Context, i int) error {
+		return walk(ctx, parents[i], func(page []T) error {
+			results[i] = append(results[i], page...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	var children []T
+	for i := range results {
+		children = append(children, results[i]...)
+	}
+	return children, nil
+}
+
+func (c *Crawler) walkPartitionPages(ctx context.Context, table *glue.TableData, pagef func([]*glue.Partition) error) error {
+	expression, ok, err := c.tableExpression(table)
+	if err != nil {
+		return err
+	}
+	c.expressionTally.add(ok)
+	if !ok {
+		return nil
+	}
+	if c.PartitionSegments > 1 {
+		return c.walkPartitionPagesSegmented(ctx, table, expression, pagef)
+	}
+	return c.walkPartitionSegment(ctx, table, expression, nil, pagef)
+}
+
+// tableExpression returns the PartitionExpression to list the partitions of
+// table with, and false when it refers to a partition key the table lacks so
+// that none of the table's partitions can match.
+func (c *Crawler) tableExpression(table *glue.TableData) (*string, bool, error) {
+	if c.PartitionExpression == "" {
+		return nil, true, nil
+	}
+	err := checkPartitionExpression(c.PartitionExpression, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("tableExpression failed to parse expression: %w", err)
+	}
+	if ValidatePartitionExpression(c.PartitionExpression, table.PartitionKeys) != nil {
+		return nil, false, nil
+	}
+	return aws.String(c.PartitionExpression), true, nil
+}
+
+func (c *Crawler) walkPartitionSegment(ctx context.Context, table *glue.TableData, expression *string, segment *glue.Segment, pagef func([]*glue.Partition) error) error {
+	return newIterator(ctx, c.partitionPages(table, expression, segment).nextPage).eachPage(pagef)
+}
+
+// partitionPages pages through the partitions of table, or of one segment of
+// it, that match expression.
+func (c *Crawler) partitionPages(table *glue.TableData, expression *string, segment *glue.Segment) *pager[*glue.Partition] {
+	return &pager[*glue.Partition]{fetch: func(ctx context.Context, token *string) ([]*glue.Partition, *string, error) {
+		input := &glue.GetPartitionsInput{
+			CatalogId:    c.glueCatalogId(),
+			DatabaseName: table.DatabaseName,
+			TableName:    table.Name,
+			Expression:   expression,
+			Segment:      segment,
+			NextToken:    token,
+		}
+		var getPartOut *glue.GetPartitionsOutput
+		err := c.call(ctx, "GetPartitions", func() (err error) {
+			getPartOut, err = c.Glue.GetPartitionsWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("partitionPages failed to get partitions for table %s.%s: %w", aws.StringValue(table.DatabaseName), aws.StringValue(table.Name), err)
+		}
+		return getPartOut.Partitions, getPartOut.NextToken, nil
+	}}
 }
 
 func (c *Crawler) SetupTestGlueDataCatalog() error {
 	_, err := c.Glue.CreateDatabase(&glue.CreateDatabaseInput{
+		CatalogId: c.glueCatalogId(),
 		DatabaseInput: &glue.DatabaseInput{
 			Name: aws.String("testdb"),
 		},
@@ -176,6 +533,7 @@ func (c *Crawler) SetupTestGlueDataCatalog() error {
 		}
 	}
 	_, err = c.Glue.CreateTable(&glue.CreateTableInput{
+		CatalogId:    c.glueCatalogId(),
 		DatabaseName: aws.String("testdb"),
 		TableInput: &glue.TableInput{
 			Name: aws.String("testtable"),
@@ -208,6 +566,7 @@ func (c *Crawler) SetupTestGlueDataCatalog() error {
 		}
 	}
 	_, err = c.Glue.CreatePartition(&glue.CreatePartitionInput{
+		CatalogId:    c.glueCatalogId(),
 		DatabaseName: aws.String("testdb"),
 		TableName:    aws.String("testtable"),
 		PartitionInput: &glue.PartitionInput{
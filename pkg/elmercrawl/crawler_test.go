@@ -1,9 +1,14 @@
 package elmercrawl
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/glue"
 	"github.com/aws/aws-sdk-go/service/glue/glueiface"
 )
@@ -14,7 +19,10 @@ type mockedGetDatabases struct {
 	RespTwo glue.GetDatabasesOutput
 }
 
-func (m mockedGetDatabases) GetDatabases(in *glue.GetDatabasesInput) (*glue.GetDatabasesOutput, error) {
+func (m mockedGetDatabases) GetDatabasesWithContext(ctx aws.Context, in *glue.GetDatabasesInput, _ ...request.Option) (*glue.GetDatabasesOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	// Only need to return mocked response output
 	if in.NextToken == nil {
 		return &m.Resp, nil
@@ -105,13 +113,58 @@ func TestCrawlDatabases(t *testing.T) {
 	}
 }
 
+func TestCrawlDatabasesWithContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	crawler := Crawler{Glue: mockedGetDatabases{}}
+	called := false
+	err := crawler.CrawlDatabasesWithContext(ctx, func(d *glue.Database) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if called {
+		t.Fatalf("expected function not to be called after cancellation")
+	}
+}
+
+func TestCrawlTablesWithContextStopsBetweenCallbacks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	crawler := Crawler{
+		Glue: mockedGetTables{
+			Resp: glue.GetTablesOutput{
+				TableList: []*glue.TableData{
+					{Name: aws.String("testtable")},
+					{Name: aws.String("testtable2")},
+				},
+			},
+		},
+		databases: []*glue.Database{{Name: aws.String("testdb")}},
+	}
+	calls := 0
+	err := crawler.CrawlTablesWithContext(ctx, func(t *glue.TableData) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call before cancellation, got %d", calls)
+	}
+}
+
 type mockedGetTables struct {
 	glueiface.GlueAPI
 	Resp    glue.GetTablesOutput
 	RespTwo glue.GetTablesOutput
 }
 
-func (m mockedGetTables) GetTables(in *glue.GetTablesInput) (*glue.GetTablesOutput, error) {
+func (m mockedGetTables) GetTablesWithContext(_ aws.Context, in *glue.GetTablesInput, _ ...request.Option) (*glue.GetTablesOutput, error) {
 	// Only need to return mocked response output
 	if in.NextToken == nil {
 		return &m.Resp, nil
@@ -224,7 +277,7 @@ type mockedGetPartitions struct {
 	RespTwo glue.GetPartitionsOutput
 }
 
-func (m mockedGetPartitions) GetPartitions(in *glue.GetPartitionsInput) (*glue.GetPartitionsOutput, error) {
+func (m mockedGetPartitions) GetPartitionsWithContext(_ aws.Context, in *glue.GetPartitionsInput, _ ...request.Option) (*glue.GetPartitionsOutput, error) {
 	// Only need to return mocked response output
 	response := &glue.GetPartitionsOutput{
 		Partitions: []*glue.Partition{},
@@ -451,3 +504,225 @@ func TestCrawlPartitions(t *testing.T) {
 		}
 	}
 }
+
+type mockedCatalog struct {
+	glueiface.GlueAPI
+	Databases  []*glue.Database
+	Tables     map[string][]*glue.TableData
+	Partitions map[string][]*glue.Partition
+	PageSize   int
+}
+
+func (m mockedCatalog) page(token *string, n int) (int, int, *string) {
+	start := 0
+	if token != nil {
+		fmt.Sscanf(*token, "%d", &start)
+	}
+	end := n
+	if m.PageSize > 0 && start+m.PageSize < n {
+		end = start + m.PageSize
+		return start, end, aws.String(fmt.Sprintf("%d", end))
+	}
+	return start, end, nil
+}
+
+func (m mockedCatalog) GetDatabasesWithContext(_ aws.Context, in *glue.GetDatabasesInput, _ ...request.Option) (*glue.GetDatabasesOutput, error) {
+	start, end, next := m.page(in.NextToken, len(m.Databases))
+	return &glue.GetDatabasesOutput{DatabaseList: m.Databases[start:end], NextToken: next}, nil
+}
+
+func (m mockedCatalog) GetTablesWithContext(_ aws.Context, in *glue.GetTablesInput, _ ...request.Option) (*glue.GetTablesOutput, error) {
+	tables := m.Tables[*in.DatabaseName]
+	start, end, next := m.page(in.NextToken, len(tables))
+	return &glue.GetTablesOutput{TableList: tables[start:end], NextToken: next}, nil
+}
+
+func (m mockedCatalog) GetPartitionsWithContext(_ aws.Context, in *glue.GetPartitionsInput, _ ...request.Option) (*glue.GetPartitionsOutput, error) {
+	partitions := m.Partitions[*in.DatabaseName+"."+*in.TableName]
+	if in.Segment != nil {
+		var segment []*glue.Partition
+		for i := range partitions {
+			if int64(i)%*in.Segment.TotalSegments == *in.Segment.SegmentNumber {
+				segment = append(segment, partitions[i])
+			}
+		}
+		partitions = segment
+	}
+	start, end, next := m.page(in.NextToken, len(partitions))
+	return &glue.GetPartitionsOutput{Partitions: partitions[start:end], NextToken: next}, nil
+}
+
+func newMockedCatalog(databases, tablesPerDatabase, partitionsPerTable int) mockedCatalog {
+	m := mockedCatalog{
+		Tables:     map[string][]*glue.TableData{},
+		Partitions: map[string][]*glue.Partition{},
+		PageSize:   2,
+	}
+	for i := 0; i < databases; i++ {
+		dbName := fmt.Sprintf("testdb%d", i)
+		m.Databases = append(m.Databases, &glue.Database{Name: aws.String(dbName)})
+		for j := 0; j < tablesPerDatabase; j++ {
+			tableName := fmt.Sprintf("testtable%d", j)
+			m.Tables[dbName] = append(m.Tables[dbName], &glue.TableData{
+				DatabaseName: aws.String(dbName),
+				Name:         aws.String(tableName),
+			})
+			for k := 0; k < partitionsPerTable; k++ {
+				m.Partitions[dbName+"."+tableName] = append(m.Partitions[dbName+"."+tableName], &glue.Partition{
+					DatabaseName: aws.String(dbName),
+					TableName:    aws.String(tableName),
+					Values:       []*string{aws.String(fmt.Sprintf("2022090%d", k))},
+				})
+			}
+		}
+	}
+	return m
+}
+
+func TestCrawlStream(t *testing.T) {
+	catalog := newMockedCatalog(3, 3, 5)
+	crawler := Crawler{Glue: catalog, Stream: true}
+
+	databases := 0
+	err := crawler.CrawlDatabases(func(d *glue.Database) error {
+		databases++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if databases != 3 {
+		t.Fatalf("expected 3 databases, got %d", databases)
+	}
+
+	tables := 0
+	err = crawler.CrawlTables(func(tbl *glue.TableData) error {
+		tables++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tables != 9 {
+		t.Fatalf("expected 9 tables, got %d", tables)
+	}
+
+	var partitions []string
+	err = crawler.CrawlPartitions(func(p *glue.Partition) error {
+		partitions = append(partitions, *p.DatabaseName+"."+*p.TableName+"/"+*p.Values[0])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(partitions) != 45 {
+		t.Fatalf("expected 45 partitions, got %d", len(partitions))
+	}
+	if partitions[0] != "testdb0.testtable0/20220900" || partitions[44] != "testdb2.testtable2/20220904" {
+		t.Fatalf("unexpected partition order: first %s, last %s", partitions[0], partitions[44])
+	}
+	if crawler.databases != nil || crawler.tables != nil || crawler.partitions != nil {
+		t.Fatalf("expected streaming crawl not to populate caches")
+	}
+}
+
+func TestCrawlPartitionsWorkers(t *testing.T) {
+	catalog := newMockedCatalog(4, 5, 3)
+	for _, workers := range []int{1, 3, 8} {
+		crawler := Crawler{Glue: catalog, Workers: workers}
+		var got []string
+		err := crawler.CrawlPartitions(func(p *glue.Partition) error {
+			got = append(got, *p.DatabaseName+"."+*p.TableName+"/"+*p.Values[0])
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%d workers, unexpected error: %v", workers, err)
+		}
+		if len(got) != 60 {
+			t.Fatalf("%d workers, expected 60 partitions, got %d", workers, len(got))
+		}
+		i := 0
+		for _, db := range catalog.Databases {
+			for _, table := range catalog.Tables[*db.Name] {
+				for _, p := range catalog.Partitions[*db.Name+"."+*table.Name] {
+					expected := *p.DatabaseName + "." + *p.TableName + "/" + *p.Values[0]
+					if got[i] != expected {
+						t.Fatalf("%d workers, expected %s at %d, got %s", workers, expected, i, got[i])
+					}
+					i++
+				}
+			}
+		}
+	}
+}
+
+func TestCrawlPartitionsParallel(t *testing.T) {
+	catalog := newMockedCatalog(2, 3, 4)
+	for _, stream := range []bool{false, true} {
+		crawler := Crawler{Glue: catalog, Stream: stream, Workers: 2, Parallel: 4}
+		var mu sync.Mutex
+		seen := map[string]int{}
+		err := crawler.CrawlPartitions(func(p *glue.Partition) error {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[*p.DatabaseName+"."+*p.TableName+"/"+*p.Values[0]]++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("stream %t, unexpected error: %v", stream, err)
+		}
+		if len(seen) != 24 {
+			t.Fatalf("stream %t, expected 24 distinct partitions, got %d", stream, len(seen))
+		}
+		for k, n := range seen {
+			if n != 1 {
+				t.Fatalf("stream %t, expected %s once, got %d", stream, k, n)
+			}
+		}
+	}
+}
+
+type mockedCatalogIdCatalog struct {
+	mockedCatalog
+	mu         *sync.Mutex
+	catalogIds map[string]bool
+}
+
+func (m mockedCatalogIdCatalog) record(catalogId *string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.catalogIds[aws.StringValue(catalogId)] = true
+}
+
+func (m mockedCatalogIdCatalog) GetDatabasesWithContext(ctx aws.Context, in *glue.GetDatabasesInput, opts ...request.Option) (*glue.GetDatabasesOutput, error) {
+	m.record(in.CatalogId)
+	return m.mockedCatalog.GetDatabasesWithContext(ctx, in, opts...)
+}
+
+func (m mockedCatalogIdCatalog) GetTablesWithContext(ctx aws.Context, in *glue.GetTablesInput, opts ...request.Option) (*glue.GetTablesOutput, error) {
+	m.record(in.CatalogId)
+	return m.mockedCatalog.GetTablesWithContext(ctx, in, opts...)
+}
+
+func (m mockedCatalogIdCatalog) GetPartitionsWithContext(ctx aws.Context, in *glue.GetPartitionsInput, opts ...request.Option) (*glue.GetPartitionsOutput, error) {
+	m.record(in.CatalogId)
+	return m.mockedCatalog.GetPartitionsWithContext(ctx, in, opts...)
+}
+
+func TestCrawlPartitionsCatalogId(t *testing.T) {
+	for _, catalogId := range []string{"", "123456789012"} {
+		mock := mockedCatalogIdCatalog{
+			mockedCatalog: newMockedCatalog(3, 3, 3),
+			mu:            &sync.Mutex{},
+			catalogIds:    map[string]bool{},
+		}
+		crawler := Crawler{Glue: mock, CatalogId: catalogId}
+		err := crawler.CrawlPartitions(func(p *glue.Partition) error { return nil })
+		if err != nil {
+			t.Fatalf("%q, unexpected error: %v", catalogId, err)
+		}
+		if len(mock.catalogIds) != 1 || !mock.catalogIds[catalogId] {
+			t.Fatalf("%q, expected every request to use catalog %q, got %v", catalogId, catalogId, mock.catalogIds)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package elmercrawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// maxBatchDeletePartitions is the largest number of partitions
+// BatchDeletePartition accepts in one request.
+const maxBatchDeletePartitions = 25
+
+// deleteThrottleRetry is how BatchDeletePartition requests are retried
+// without a Retry policy: only when they are throttled, which a deletion
+// always retries whatever the crawler's Retry allows.
+var deleteThrottleRetry = RetryPolicy{
+	MaxAttempts:      1,
+	BaseDelay:        time.Second,
+	MaxDelay:         30 * time.Second,
+	Jitter:           0.5,
+	throttleAttempts: 5,
+}
+
+// PartitionDeleteBatch is one BatchDeletePartition request for partitions of
+// a single table.
+type PartitionDeleteBatch struct {
+	Database   string
+	Table      string
+	Partitions []*glue.Partition
+	// DryRun is set when the batch was not sent to Glue.
+	DryRun bool
+	// Errors are the partitions of the batch Glue failed to delete.
+	Errors []*glue.PartitionError
+	// Err is set when the request failed as a whole, after any retries.
+	Err error
+}
+
+// Object names the table of the batch.
+func (b *PartitionDeleteBatch) Object() string {
+	return b.Database + "." + b.Table
+}
+
+type gluePartitionDeleteBatchFunc func(*PartitionDeleteBatch) error
+
+func (c *Crawler) DeletePartitions(dryRun bool, pdbf gluePartitionDeleteBatchFunc) error {
+	return c.DeletePartitionsWithContext(context.Background(), dryRun, pdbf)
+}
+
+// DeletePartitionsWithContext lists the partitions that pass the crawler's
+// filters and PartitionExpression from Glue, never from the cache, then
+// deletes them like DeletePartitionListWithContext. Without a
+// PartitionExpression it refuses to run unless AllowDeleteAll is set.
+func (c *Crawler) DeletePartitionsWithContext(ctx context.Context, dryRun bool, pdbf gluePartitionDeleteBatchFunc) error {
+	if c.PartitionExpression == "" && !c.AllowDeleteAll {
+		return errors.New("DeletePartitions requires a PartitionExpression unless AllowDeleteAll is set")
+	}
+	var partitions []*glue.Partition
+	it := c.Partitions(ctx)
+	defer it.Close()
+	for it.Next() {
+		partitions = append(partitions, it.Value())
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("DeletePartitions failed to list partitions: %w", err)
+	}
+	return c.DeletePartitionListWithContext(ctx, partitions, dryRun, pdbf)
+}
+
+func (c *Crawler) DeletePartitionList(partitions []*glue.Partition, dryRun bool, pdbf gluePartitionDeleteBatchFunc) error {
+	return c.DeletePartitionListWithContext(context.Background(), partitions, dryRun, pdbf)
+}
+
+// DeletePartitionListWithContext deletes partitions, such as the ones listed
+// by a dry run, in batches of up to 25 partitions of the same table, on up to
+// Parallel goroutines. pdbf is called with the outcome of every batch; with
+// dryRun set no request is sent and every batch is only passed to pdbf.
+//
+// Throttled requests are retried at least as often as deleteThrottleRetry
+// allows, even without a Retry policy. A failed request ends the deletion
+// unless KeepGoing is set. Partitions Glue
+// reports it could not delete never end it, and are returned together with
+// any failed requests kept going past as a *MultiError. Cached partitions of
+// the tables deleted from are invalidated.
+func (c *Crawler) DeletePartitionListWithContext(ctx context.Context, partitions []*glue.Partition, dryRun bool, pdbf gluePartitionDeleteBatchFunc) error {
+	batches := partitionDeleteBatches(partitions)
+	failures := c.newErrorCollector()
+	err := callEach(ctx, batches, c.Parallel, nil, func(batch *PartitionDeleteBatch) error {
+		batch.DryRun = dryRun
+		if !dryRun {
+			batch.Err = c.batchDeletePartition(ctx, batch)
+		}
+		for _, e := range batch.Errors {
+			failures.add(&ObjectError{
+				Database:  batch.Database,
+				Table:     batch.Table,
+				Partition: aws.StringValueSlice(e.PartitionValues),
+				Err:       partitionError(e),
+			})
+		}
+		err := pdbf(batch)
+		if err == nil {
+			err = batch.Err
+		}
+		if err != nil && c.KeepGoing {
+			failures.add(&ObjectError{Database: batch.Database, Table: batch.Table, Err: err})
+			return nil
+		}
+		return err
+	})
+	if !dryRun {
+		for _, batch := range batches {
+			c.InvalidateTable(batch.Database, batch.Table)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("DeletePartitionList failed to delete partitions: %w", err)
+	}
+	return failures.err()
+}
+
+func (c *Crawler) batchDeletePartition(ctx context.Context, batch *PartitionDeleteBatch) error {
+	input := &glue.BatchDeletePartitionInput{
+		CatalogId:    c.glueCatalogId(),
+		DatabaseName: aws.String(batch.Database),
+		TableName:    aws.String(batch.Table),
+	}
+	for _, partition := range batch.Partitions {
+		input.PartitionsToDelete = append(input.PartitionsToDelete, &glue.PartitionValueList{Values: partition.Values})
+	}
+	var deleteOut *glue.BatchDeletePartitionOutput
+	err := c.callWithRetry(ctx, "BatchDeletePartition", c.deleteRetryPolicy(), func() (err error) {
+		deleteOut, err = c.Glue.BatchDeletePartitionWithContext(ctx, input)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("batchDeletePartition failed to delete %d partitions of table %s.%s: %w", len(batch.Partitions), batch.Database, batch.Table, err)
+	}
+	batch.Errors = deleteOut.Errors
+	return nil
+}
+
+// deleteRetryPolicy extends the crawler's Retry, if any, so that throttled
+// requests are retried as often as deleteThrottleRetry allows. Other errors
+// are retried as the crawler's Retry says.
+func (c *Crawler) deleteRetryPolicy() *RetryPolicy {
+	policy := deleteThrottleRetry
+	if c.Retry != nil {
+		policy = *c.Retry
+		policy.throttleAttempts = deleteThrottleRetry.throttleAttempts
+	}
+	return &policy
+}
+
+// partitionDeleteBatches groups partitions by table, in the order their
+// tables first appear, and splits each table's partitions into batches
+// BatchDeletePartition accepts.
+func partitionDeleteBatches(partitions []*glue.Partition) []*PartitionDeleteBatch {
+	var keys []string
+	byTable := map[string][]*glue.Partition{}
+	for _, partition := range partitions {
+		key := aws.StringValue(partition.DatabaseName) + "." + aws.StringValue(partition.TableName)
+		if _, ok := byTable[key]; !ok {
+			keys = append(keys, key)
+		}
+		byTable[key] = append(byTable[key], partition)
+	}
+	var batches []*PartitionDeleteBatch
+	for _, key := range keys {
+		table := byTable[key]
+		for start := 0; start < len(table); start += maxBatchDeletePartitions {
+			end := start + maxBatchDeletePartitions
+			if end > len(table) {
+				end = len(table)
+			}
+			batches = append(batches, &PartitionDeleteBatch{
+				Database:   aws.StringValue(table[0].DatabaseName),
+				Table:      aws.StringValue(table[0].TableName),
+				Partitions: table[start:end],
+			})
+		}
+	}
+	return batches
+}
+
+// partitionError turns the error Glue reported for one partition into an
+// error.
+func partitionError(e *glue.PartitionError) error {
+	if e.ErrorDetail == nil {
+		return errors.New("partition was not deleted")
+	}
+	return fmt.Errorf("%s: %s", aws.StringValue(e.ErrorDetail.ErrorCode), aws.StringValue(e.ErrorDetail.ErrorMessage))
+}
@@ -0,0 +1,195 @@
+package elmercrawl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// mockedBatchDeletePartition deletes partitions from its catalog, except the
+// one with the value failValue, and throttles the first throttled requests.
+type mockedBatchDeletePartition struct {
+	mockedCatalog
+	failValue string
+	throttled int
+	requests  *[]int
+}
+
+func (m mockedBatchDeletePartition) BatchDeletePartitionWithContext(ctx aws.Context, in *glue.BatchDeletePartitionInput, opts ...request.Option) (*glue.BatchDeletePartitionOutput, error) {
+	*m.requests = append(*m.requests, len(in.PartitionsToDelete))
+	if len(*m.requests) <= m.throttled {
+		return nil, awserr.New("ThrottlingException", "Rate exceeded", nil)
+	}
+	out := &glue.BatchDeletePartitionOutput{}
+	key := aws.StringValue(in.DatabaseName) + "." + aws.StringValue(in.TableName)
+	deleted := map[string]bool{}
+	for _, values := range in.PartitionsToDelete {
+		value := aws.StringValue(values.Values[0])
+		if value == m.failValue {
+			out.Errors = append(out.Errors, &glue.PartitionError{
+				PartitionValues: values.Values,
+				ErrorDetail:     &glue.ErrorDetail{ErrorCode: aws.String("InternalServiceException"), ErrorMessage: aws.String("mocked failure")},
+			})
+			continue
+		}
+		deleted[value] = true
+	}
+	var kept []*glue.Partition
+	for _, partition := range m.Partitions[key] {
+		if !deleted[aws.StringValue(partition.Values[0])] {
+			kept = append(kept, partition)
+		}
+	}
+	m.Partitions[key] = kept
+	return out, nil
+}
+
+// fastDeleteRetry shortens the delays of deleteThrottleRetry for a test.
+func fastDeleteRetry(t *testing.T) {
+	retry := deleteThrottleRetry
+	deleteThrottleRetry.BaseDelay = time.Millisecond
+	t.Cleanup(func() { deleteThrottleRetry = retry })
+}
+
+func TestDeletePartitions(t *testing.T) {
+	fastDeleteRetry(t)
+	catalog := newMockedCatalog(1, 2, 30)
+	var requests []int
+	crawler := Crawler{
+		Glue:           mockedBatchDeletePartition{mockedCatalog: catalog, failValue: "20220901", throttled: 1, requests: &requests},
+		AllowDeleteAll: true,
+	}
+
+	var previewed int
+	err := crawler.DeletePartitions(true, func(batch *PartitionDeleteBatch) error {
+		if !batch.DryRun {
+			t.Fatalf("expected a dry run batch")
+		}
+		previewed += len(batch.Partitions)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if previewed != 60 || len(requests) != 0 {
+		t.Fatalf("expected 60 partitions previewed and no requests, got %d and %v", previewed, requests)
+	}
+
+	var batches []string
+	err = crawler.DeletePartitions(false, func(batch *PartitionDeleteBatch) error {
+		batches = append(batches, batch.Object())
+		return batch.Err
+	})
+	var multi *MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 || multi.Errors[0].Object() != "testdb0.testtable0/20220901" {
+		t.Fatalf("expected the two undeletable partitions to be reported, got %v", err)
+	}
+	if len(batches) != 4 || batches[0] != "testdb0.testtable0" || batches[3] != "testdb0.testtable1" {
+		t.Fatalf("expected two batches per table, got %v", batches)
+	}
+	// The throttled first request is retried without a retry policy.
+	if len(requests) != 5 || requests[0] != 25 || requests[2] != 5 {
+		t.Fatalf("unexpected requests %v", requests)
+	}
+	if len(catalog.Partitions["testdb0.testtable0"]) != 1 || len(catalog.Partitions["testdb0.testtable1"]) != 1 {
+		t.Fatalf("expected one partition left per table, got %v", catalog.Partitions)
+	}
+}
+
+func TestDeletePartitionsListsFromGlue(t *testing.T) {
+	catalog := newMockedCatalog(1, 2, 3)
+	catalog.Tables["testdb0"][1].PartitionKeys = []*glue.Column{{Name: aws.String("logdate")}}
+	var requests []int
+	crawler := Crawler{Glue: mockedBatchDeletePartition{mockedCatalog: catalog, requests: &requests}}
+	err := crawler.DeletePartitions(false, func(*PartitionDeleteBatch) error { return nil })
+	if err == nil || len(requests) != 0 {
+		t.Fatalf("expected a deletion without expression to be refused, got %v and %v", err, requests)
+	}
+
+	// Fill the partition cache, then delete with an expression only
+	// testtable1 has the keys for.
+	if got := crawledPartitions(t, &crawler); len(got) != 6 {
+		t.Fatalf("expected 6 partitions, got %v", got)
+	}
+	crawler.PartitionExpression = "logdate < 20220903"
+	var deleted []string
+	err = crawler.DeletePartitions(false, func(batch *PartitionDeleteBatch) error {
+		deleted = append(deleted, batch.Object())
+		return batch.Err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "testdb0.testtable1" || len(catalog.Partitions["testdb0.testtable0"]) != 3 {
+		t.Fatalf("expected only the partitions of testtable1 to be deleted, got %v", deleted)
+	}
+}
+
+func TestDeletePartitionListRequestFailure(t *testing.T) {
+	fastDeleteRetry(t)
+	catalog := newMockedCatalog(1, 2, 3)
+	var requests []int
+	partitions := append(catalog.Partitions["testdb0.testtable0"], catalog.Partitions["testdb0.testtable1"]...)
+	for _, keepGoing := range []bool{false, true} {
+		requests = nil
+		crawler := Crawler{
+			Glue:      mockedBatchDeletePartition{mockedCatalog: catalog, throttled: deleteThrottleRetry.throttleAttempts, requests: &requests},
+			KeepGoing: keepGoing,
+		}
+		var failed []string
+		err := crawler.DeletePartitionList(partitions, false, func(batch *PartitionDeleteBatch) error {
+			if batch.Err != nil {
+				failed = append(failed, batch.Object())
+			}
+			return nil
+		})
+		if err == nil || len(failed) != 1 || failed[0] != "testdb0.testtable0" {
+			t.Fatalf("keep going %v, expected the persistently throttled batch to fail, got %v and %v", keepGoing, failed, err)
+		}
+		var throttled *ThrottledError
+		if !errors.As(err, &throttled) {
+			t.Fatalf("keep going %v, expected a throttling error, got %v", keepGoing, err)
+		}
+		if want := map[bool]int{false: 5, true: 6}[keepGoing]; len(requests) != want {
+			t.Fatalf("keep going %v, expected %d requests, got %d", keepGoing, want, len(requests))
+		}
+	}
+}
+
+func TestDeleteRetryPolicy(t *testing.T) {
+	throttle := &ThrottledError{API: "BatchDeletePartition", Err: awserr.New("ThrottlingException", "Rate exceeded", nil)}
+	internal := awserr.New("InternalServiceException", "mocked failure", nil)
+	cases := []struct {
+		Retry    *RetryPolicy
+		Err      error
+		Attempts int
+	}{
+		{Retry: nil, Err: throttle, Attempts: 5},
+		{Retry: nil, Err: internal, Attempts: 1},
+		{Retry: &RetryPolicy{MaxAttempts: 2, RetryableCodes: []string{"InternalServiceException"}}, Err: throttle, Attempts: 5},
+		{Retry: &RetryPolicy{MaxAttempts: 2}, Err: internal, Attempts: 2},
+		{Retry: &RetryPolicy{MaxAttempts: 7}, Err: throttle, Attempts: 7},
+	}
+	for i, c := range cases {
+		crawler := Crawler{Retry: c.Retry}
+		policy := crawler.deleteRetryPolicy()
+		policy.BaseDelay = 0
+		attempts := 0
+		err := policy.retry(context.Background(), "BatchDeletePartition", func() error {
+			attempts++
+			return c.Err
+		})
+		if !errors.Is(err, c.Err) || attempts != c.Attempts {
+			t.Fatalf("%d, expected %d attempts, got %d and %v", i, c.Attempts, attempts, err)
+		}
+	}
+	if crawler := (Crawler{Retry: &RetryPolicy{MaxAttempts: 2}}); crawler.deleteRetryPolicy() == crawler.Retry || crawler.Retry.throttleAttempts != 0 {
+		t.Fatalf("expected the crawler's policy to be left alone")
+	}
+}
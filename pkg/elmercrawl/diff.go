@@ -0,0 +1,319 @@
+package elmercrawl
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// ChangeKind says how an object differs between two catalog states.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is one difference between two catalog states. A change to a whole
+// database, table or partition leaves Column, PartitionKey, Parameter and
+// Attribute empty; otherwise exactly one of them names what differs within
+// the object.
+type Change struct {
+	Kind         ChangeKind
+	Database     string
+	Table        string   `json:",omitempty"`
+	Partition    []string `json:",omitempty"`
+	Column       string   `json:",omitempty"`
+	PartitionKey string   `json:",omitempty"`
+	Parameter    string   `json:",omitempty"`
+	Attribute    string   `json:",omitempty"`
+	// Old and New hold the differing values of a column type, parameter or
+	// attribute.
+	Old string `json:",omitempty"`
+	New string `json:",omitempty"`
+}
+
+// Object names the database, table or partition the change belongs to.
+func (c *Change) Object() string {
+	object := c.Database
+	if c.Table != "" {
+		object += "." + c.Table
+	}
+	if c.Partition != nil {
+		object += "/" + strings.Join(c.Partition, "/")
+	}
+	return object
+}
+
+func (c *Change) String() string {
+	sign := map[ChangeKind]string{Added: "+", Removed: "-", Changed: "~"}[c.Kind]
+	var what string
+	switch {
+	case c.Column != "":
+		what = "column " + c.Object() + "." + c.Column
+	case c.PartitionKey != "":
+		what = "partition key " + c.Object() + "." + c.PartitionKey
+	case c.Parameter != "":
+		what = "parameter " + c.Object() + " " + c.Parameter
+	case c.Attribute != "":
+		what = c.Object() + " " + c.Attribute
+	case c.Partition != nil:
+		what = "partition " + c.Object()
+	case c.Table != "":
+		what = "table " + c.Object()
+	default:
+		what = "database " + c.Object()
+	}
+	switch c.Kind {
+	case Added:
+		if c.New != "" {
+			what += " = " + c.New
+		}
+	case Removed:
+		if c.Old != "" {
+			what += " = " + c.Old
+		}
+	case Changed:
+		what += ": " + c.Old + " -> " + c.New
+	}
+	return sign + " " + what
+}
+
+// DiffSnapshots returns the databases, tables, columns, partitions and
+// parameters that differ from old to new, ordered by object. The children of
+// an added or removed database or table are not reported separately.
+func DiffSnapshots(old, new *Snapshot) []Change {
+	d := &differ{}
+	oldDbs, newDbs := databasesByName(old.Databases), databasesByName(new.Databases)
+	for _, name := range unionKeys(oldDbs, newDbs) {
+		o, n := oldDbs[name], newDbs[name]
+		switch {
+		case o == nil:
+			d.add(Change{Kind: Added, Database: name})
+		case n == nil:
+			d.add(Change{Kind: Removed, Database: name})
+		default:
+			base := Change{Database: name}
+			d.attribute(base, "description", o.Description, n.Description)
+			d.attribute(base, "location", o.LocationUri, n.LocationUri)
+			d.parameters(base, o.Parameters, n.Parameters)
+		}
+	}
+
+	oldTables, newTables := tablesByKey(old.Tables), tablesByKey(new.Tables)
+	for _, key := range unionKeys(oldTables, newTables) {
+		o, n := oldTables[key], newTables[key]
+		switch {
+		case o == nil:
+			if oldDbs[aws.StringValue(n.DatabaseName)] != nil {
+				d.add(Change{Kind: Added, Database: aws.StringValue(n.DatabaseName), Table: aws.StringValue(n.Name)})
+			}
+		case n == nil:
+			if newDbs[aws.StringValue(o.DatabaseName)] != nil {
+				d.add(Change{Kind: Removed, Database: aws.StringValue(o.DatabaseName), Table: aws.StringValue(o.Name)})
+			}
+		default:
+			base := Change{Database: aws.StringValue(n.DatabaseName), Table: aws.StringValue(n.Name)}
+			d.attribute(base, "table type", o.TableType, n.TableType)
+			d.attribute(base, "description", o.Description, n.Description)
+			d.storage(base, o.StorageDescriptor, n.StorageDescriptor)
+			d.columns(base, o.PartitionKeys, n.PartitionKeys, func(c *Change, name string) { c.PartitionKey = name })
+			d.parameters(base, o.Parameters, n.Parameters)
+		}
+	}
+
+	oldParts, newParts := partitionsByKey(old.Partitions), partitionsByKey(new.Partitions)
+	for _, key := range unionKeys(oldParts, newParts) {
+		o, n := oldParts[key], newParts[key]
+		p := n
+		if p == nil {
+			p = o
+		}
+		base := Change{
+			Database:  aws.StringValue(p.DatabaseName),
+			Table:     aws.StringValue(p.TableName),
+			Partition: aws.StringValueSlice(p.Values),
+		}
+		table := base.Database + "." + base.Table
+		switch {
+		case o == nil:
+			if oldTables[table] != nil {
+				base.Kind = Added
+				d.add(base)
+			}
+		case n == nil:
+			if newTables[table] != nil {
+				base.Kind = Removed
+				d.add(base)
+			}
+		default:
+			d.storage(base, o.StorageDescriptor, n.StorageDescriptor)
+			d.parameters(base, o.Parameters, n.Parameters)
+		}
+	}
+
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		a, b := d.changes[i], d.changes[j]
+		if a.Database != b.Database {
+			return a.Database < b.Database
+		}
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		return strings.Join(a.Partition, "/") < strings.Join(b.Partition, "/")
+	})
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) attribute(base Change, name string, old, new *string) {
+	if aws.StringValue(old) == aws.StringValue(new) {
+		return
+	}
+	base.Kind = Changed
+	base.Attribute = name
+	base.Old = aws.StringValue(old)
+	base.New = aws.StringValue(new)
+	d.add(base)
+}
+
+func (d *differ) storage(base Change, old, new *glue.StorageDescriptor) {
+	if old == nil {
+		old = &glue.StorageDescriptor{}
+	}
+	if new == nil {
+		new = &glue.StorageDescriptor{}
+	}
+	d.attribute(base, "location", old.Location, new.Location)
+	d.attribute(base, "input format", old.InputFormat, new.InputFormat)
+	d.attribute(base, "output format", old.OutputFormat, new.OutputFormat)
+	var oldSerde, newSerde *string
+	if old.SerdeInfo != nil {
+		oldSerde = old.SerdeInfo.SerializationLibrary
+	}
+	if new.SerdeInfo != nil {
+		newSerde = new.SerdeInfo.SerializationLibrary
+	}
+	d.attribute(base, "serde", oldSerde, newSerde)
+	d.columns(base, old.Columns, new.Columns, func(c *Change, name string) { c.Column = name })
+}
+
+// columns reports added and removed columns, and columns whose type
+// changed. name sets the column name on a change.
+func (d *differ) columns(base Change, old, new []*glue.Column, name func(*Change, string)) {
+	oldTypes, newTypes := columnTypes(old), columnTypes(new)
+	for _, column := range unionKeys(oldTypes, newTypes) {
+		o, inOld := oldTypes[column]
+		n, inNew := newTypes[column]
+		c := base
+		name(&c, column)
+		switch {
+		case !inOld:
+			c.Kind, c.New = Added, n
+		case !inNew:
+			c.Kind, c.Old = Removed, o
+		case o != n:
+			c.Kind, c.Old, c.New = Changed, o, n
+		default:
+			continue
+		}
+		d.add(c)
+	}
+}
+
+func (d *differ) parameters(base Change, old, new map[string]*string) {
+	for _, key := range unionKeys(old, new) {
+		o, inOld := old[key]
+		n, inNew := new[key]
+		c := base
+		c.Parameter = key
+		switch {
+		case !inOld:
+			c.Kind, c.New = Added, aws.StringValue(n)
+		case !inNew:
+			c.Kind, c.Old = Removed, aws.StringValue(o)
+		case aws.StringValue(o) != aws.StringValue(n):
+			c.Kind, c.Old, c.New = Changed, aws.StringValue(o), aws.StringValue(n)
+		default:
+			continue
+		}
+		d.add(c)
+	}
+}
+
+func columnTypes(columns []*glue.Column) map[string]string {
+	types := make(map[string]string, len(columns))
+	for _, column := range columns {
+		types[aws.StringValue(column.Name)] = aws.StringValue(column.Type)
+	}
+	return types
+}
+
+func databasesByName(databases []*glue.Database) map[string]*glue.Database {
+	byName := make(map[string]*glue.Database, len(databases))
+	for _, db := range databases {
+		byName[aws.StringValue(db.Name)] = db
+	}
+	return byName
+}
+
+func tablesByKey(tables []*glue.TableData) map[string]*glue.TableData {
+	byKey := make(map[string]*glue.TableData, len(tables))
+	for _, table := range tables {
+		byKey[aws.StringValue(table.DatabaseName)+"."+aws.StringValue(table.Name)] = table
+	}
+	return byKey
+}
+
+func partitionsByKey(partitions []*glue.Partition) map[string]*glue.Partition {
+	byKey := make(map[string]*glue.Partition, len(partitions))
+	for _, p := range partitions {
+		key := aws.StringValue(p.DatabaseName) + "." + aws.StringValue(p.TableName) + "/" + strings.Join(aws.StringValueSlice(p.Values), "/")
+		byKey[key] = p
+	}
+	return byKey
+}
+
+// unionKeys returns the keys of a and b in sorted order.
+func unionKeys[V any](a, b map[string]V) []string {
+	var keys []string
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FilterChanges returns the changes that do not concern one of the given
+// parameters, such as ones Glue updates on its own.
+func FilterChanges(changes []Change, ignoreParameters []string) []Change {
+	if len(ignoreParameters) == 0 {
+		return changes
+	}
+	ignore := map[string]bool{}
+	for _, p := range ignoreParameters {
+		ignore[p] = true
+	}
+	kept := changes[:0:0]
+	for _, c := range changes {
+		if c.Parameter == "" || !ignore[c.Parameter] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,80 @@
+package elmercrawl
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	old := &Snapshot{
+		Databases: []*glue.Database{{Name: aws.String("db0")}, {Name: aws.String("db1")}},
+		Tables: []*glue.TableData{
+			{
+				DatabaseName: aws.String("db0"),
+				Name:         aws.String("t0"),
+				StorageDescriptor: &glue.StorageDescriptor{
+					Columns: []*glue.Column{
+						{Name: aws.String("id"), Type: aws.String("int")},
+						{Name: aws.String("gone"), Type: aws.String("string")},
+					},
+				},
+				Parameters: map[string]*string{"classification": aws.String("json")},
+			},
+			{DatabaseName: aws.String("db1"), Name: aws.String("t0")},
+		},
+		Partitions: []*glue.Partition{
+			{DatabaseName: aws.String("db0"), TableName: aws.String("t0"), Values: aws.StringSlice([]string{"2022"})},
+			{DatabaseName: aws.String("db1"), TableName: aws.String("t0"), Values: aws.StringSlice([]string{"2022"})},
+		},
+	}
+	new := &Snapshot{
+		Databases: []*glue.Database{{Name: aws.String("db0")}, {Name: aws.String("db2")}},
+		Tables: []*glue.TableData{
+			{
+				DatabaseName: aws.String("db0"),
+				Name:         aws.String("t0"),
+				StorageDescriptor: &glue.StorageDescriptor{
+					Columns: []*glue.Column{
+						{Name: aws.String("id"), Type: aws.String("bigint")},
+						{Name: aws.String("name"), Type: aws.String("string")},
+					},
+				},
+				Parameters: map[string]*string{"classification": aws.String("parquet")},
+			},
+			{DatabaseName: aws.String("db0"), Name: aws.String("t1")},
+			{DatabaseName: aws.String("db2"), Name: aws.String("t0")},
+		},
+		Partitions: []*glue.Partition{
+			{DatabaseName: aws.String("db0"), TableName: aws.String("t0"), Values: aws.StringSlice([]string{"2023"})},
+			{DatabaseName: aws.String("db2"), TableName: aws.String("t0"), Values: aws.StringSlice([]string{"2023"})},
+		},
+	}
+	changes := DiffSnapshots(old, new)
+	expected := []string{
+		"- column db0.t0.gone = string",
+		"~ column db0.t0.id: int -> bigint",
+		"+ column db0.t0.name = string",
+		"~ parameter db0.t0 classification: json -> parquet",
+		"- partition db0.t0/2022",
+		"+ partition db0.t0/2023",
+		"+ table db0.t1",
+		"- database db1",
+		"+ database db2",
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %v", len(expected), changes)
+	}
+	for i := range expected {
+		if changes[i].String() != expected[i] {
+			t.Fatalf("expected %q at %d, got %q", expected[i], i, changes[i].String())
+		}
+	}
+	if len(DiffSnapshots(new, new)) != 0 {
+		t.Fatalf("expected no changes between identical snapshots")
+	}
+	if len(FilterChanges(changes, []string{"classification"})) != len(expected)-1 {
+		t.Fatalf("expected the classification change to be filtered out")
+	}
+}
@@ -0,0 +1,202 @@
+package elmercrawl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// ObjectError is the failure of a crawl function for one database, table or
+// partition.
+type ObjectError struct {
+	Region     string
+	CatalogId  string
+	Database   string
+	Table      string
+	Partition  []string
+	Version    string
+	Function   string
+	Connection string
+	Index      string
+	Err        error
+}
+
+func (e *ObjectError) Object() string {
+	if e.Connection != "" {
+		return "connection " + e.Connection
+	}
+	object := e.Database
+	if e.Table != "" {
+		object += "." + e.Table
+	}
+	if e.Partition != nil {
+		object += "/" + strings.Join(e.Partition, "/")
+	}
+	if e.Version != "" {
+		object += "@" + e.Version
+	}
+	if e.Function != "" {
+		object += "." + e.Function + "()"
+	}
+	if e.Index != "" {
+		object += "#" + e.Index
+	}
+	if object == "" {
+		// The failure of a whole region or catalog.
+		object = "region " + e.Region
+		if e.CatalogId != "" {
+			object += " catalog " + e.CatalogId
+		}
+	}
+	return object
+}
+
+func (e *ObjectError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Object(), e.Err)
+}
+
+func (e *ObjectError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is returned by a crawl with KeepGoing set when the crawl
+// function failed for at least one object.
+type MultiError struct {
+	Errors []*ObjectError
+}
+
+func (e *MultiError) Error() string {
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("1 object failed: %v", e.Errors[0])
+	}
+	return fmt.Sprintf("%d objects failed, first: %v", len(e.Errors), e.Errors[0])
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i := range e.Errors {
+		errs[i] = e.Errors[i]
+	}
+	return errs
+}
+
+type errorCollector struct {
+	region    string
+	catalogId string
+	mu        sync.Mutex
+	errs      []*ObjectError
+}
+
+func (c *Crawler) newErrorCollector() *errorCollector {
+	return &errorCollector{region: c.Region, catalogId: c.CatalogId}
+}
+
+func (ec *errorCollector) add(e *ObjectError) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	e.Region = ec.region
+	e.CatalogId = ec.catalogId
+	ec.errs = append(ec.errs, e)
+}
+
+// err returns the collected errors ordered by object, or nil when nothing
+// failed.
+func (ec *errorCollector) err() error {
+	if ec == nil || len(ec.errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(ec.errs, func(i, j int) bool {
+		return ec.errs[i].Object() < ec.errs[j].Object()
+	})
+	return &MultiError{Errors: ec.errs}
+}
+
+func (ec *errorCollector) databaseFunc(gdbf glueDBFunc) glueDBFunc {
+	return func(db *glue.Database) error {
+		if err := gdbf(db); err != nil {
+			ec.add(&ObjectError{Database: aws.StringValue(db.Name), Err: err})
+		}
+		return nil
+	}
+}
+
+func (ec *errorCollector) tableFunc(gtf glueTableFunc) glueTableFunc {
+	return func(table *glue.TableData) error {
+		if err := gtf(table); err != nil {
+			ec.add(&ObjectError{
+				Database: aws.StringValue(table.DatabaseName),
+				Table:    aws.StringValue(table.Name),
+				Err:      err,
+			})
+		}
+		return nil
+	}
+}
+
+func (ec *errorCollector) partitionFunc(tpf tablePartitionFunc) tablePartitionFunc {
+	return func(table *glue.TableData, partition *glue.Partition) error {
+		if err := tpf(table, partition); err != nil {
+			ec.add(&ObjectError{
+				Database:  aws.StringValue(partition.DatabaseName),
+				Table:     aws.StringValue(partition.TableName),
+				Partition: aws.StringValueSlice(partition.Values),
+				Err:       err,
+			})
+		}
+		return nil
+	}
+}
+
+func (ec *errorCollector) tableVersionFunc(gtvf glueTableVersionFunc) glueTableVersionFunc {
+	return func(version *glue.TableVersion) error {
+		if err := gtvf(version); err != nil {
+			e := &ObjectError{Version: aws.StringValue(version.VersionId), Err: err}
+			if version.Table != nil {
+				e.Database = aws.StringValue(version.Table.DatabaseName)
+				e.Table = aws.StringValue(version.Table.Name)
+			}
+			ec.add(e)
+		}
+		return nil
+	}
+}
+
+func (ec *errorCollector) functionFunc(gff glueFunctionFunc) glueFunctionFunc {
+	return func(function *glue.UserDefinedFunction) error {
+		if err := gff(function); err != nil {
+			ec.add(&ObjectError{
+				Database: aws.StringValue(function.DatabaseName),
+				Function: aws.StringValue(function.FunctionName),
+				Err:      err,
+			})
+		}
+		return nil
+	}
+}
+
+func (ec *errorCollector) connectionFunc(gcf glueConnectionFunc) glueConnectionFunc {
+	return func(connection *glue.Connection) error {
+		if err := gcf(connection); err != nil {
+			ec.add(&ObjectError{Connection: aws.StringValue(connection.Name), Err: err})
+		}
+		return nil
+	}
+}
+
+func (ec *errorCollector) partitionIndexFunc(gpif gluePartitionIndexFunc) gluePartitionIndexFunc {
+	return func(index *PartitionIndex) error {
+		if err := gpif(index); err != nil {
+			ec.add(&ObjectError{
+				Database: aws.StringValue(index.Table.DatabaseName),
+				Table:    aws.StringValue(index.Table.Name),
+				Index:    aws.StringValue(index.Index.IndexName),
+				Err:      err,
+			})
+		}
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package elmercrawl
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+func TestCrawlPartitionsKeepGoing(t *testing.T) {
+	errBoom := errors.New("boom")
+	for _, stream := range []bool{false, true} {
+		crawler := Crawler{
+			Glue:      newMockedCatalog(2, 2, 3),
+			Stream:    stream,
+			Parallel:  3,
+			KeepGoing: true,
+		}
+		var calls int32
+		err := crawler.CrawlPartitions(func(p *glue.Partition) error {
+			atomic.AddInt32(&calls, 1)
+			if *p.Values[0] == "20220901" {
+				return errBoom
+			}
+			return nil
+		})
+		if calls != 12 {
+			t.Fatalf("stream %t, expected 12 calls, got %d", stream, calls)
+		}
+		var multiErr *MultiError
+		if !errors.As(err, &multiErr) {
+			t.Fatalf("stream %t, expected MultiError, got %v", stream, err)
+		}
+		if len(multiErr.Errors) != 4 {
+			t.Fatalf("stream %t, expected 4 failures, got %d", stream, len(multiErr.Errors))
+		}
+		for i, e := range multiErr.Errors {
+			expected := fmt.Sprintf("testdb%d.testtable%d/20220901", i/2, i%2)
+			if e.Object() != expected {
+				t.Fatalf("stream %t, expected failure %d for %s, got %s", stream, i, expected, e.Object())
+			}
+			if !errors.Is(e, errBoom) {
+				t.Fatalf("stream %t, expected failure %d to wrap boom, got %v", stream, i, e.Err)
+			}
+		}
+	}
+}
+
+func TestCrawlDatabasesKeepGoingNoFailures(t *testing.T) {
+	crawler := Crawler{Glue: newMockedCatalog(3, 0, 0), KeepGoing: true}
+	err := crawler.CrawlDatabases(func(d *glue.Database) error { return nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCrawlTablesKeepGoingLabelsFailures(t *testing.T) {
+	crawler := Crawler{
+		Glue:      newMockedCatalog(1, 2, 0),
+		CatalogId: "123456789012",
+		Region:    "eu-west-1",
+		KeepGoing: true,
+	}
+	err := crawler.CrawlTables(func(table *glue.TableData) error { return errors.New("boom") })
+	var multiErr *MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("expected MultiError, got %v", err)
+	}
+	for _, e := range multiErr.Errors {
+		if e.CatalogId != "123456789012" || e.Region != "eu-west-1" {
+			t.Fatalf("expected failure labelled with catalog 123456789012 in eu-west-1, got %q in %q", e.CatalogId, e.Region)
+		}
+	}
+}
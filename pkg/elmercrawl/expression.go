@@ -0,0 +1,307 @@
+package elmercrawl
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// ValidatePartitionExpression checks that expression is a well formed
+// GetPartitions filter expression that only refers to the given partition
+// keys.
+func ValidatePartitionExpression(expression string, partitionKeys []*glue.Column) error {
+	keys := map[string]bool{}
+	for _, key := range partitionKeys {
+		keys[strings.ToLower(aws.StringValue(key.Name))] = true
+	}
+	return checkPartitionExpression(expression, keys)
+}
+
+// expressionTally counts, over one partition crawl, the tables listed and
+// the ones having the partition keys PartitionExpression refers to. Its
+// methods do nothing on a nil tally.
+type expressionTally struct {
+	mu      sync.Mutex
+	tables  int
+	matched int
+}
+
+func (t *expressionTally) add(ok bool) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tables++
+	if ok {
+		t.matched++
+	}
+}
+
+// err reports an expression that no listed table could match, such as one
+// with a misspelled key.
+func (t *expressionTally) err(expression string) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tables == 0 || t.matched != 0 {
+		return nil
+	}
+	return fmt.Errorf("none of the %d tables has the partition keys expression %q refers to", t.tables, expression)
+}
+
+// checkPartitionExpression parses expression and, unless keys is nil, checks
+// every column it refers to against keys.
+func checkPartitionExpression(expression string, keys map[string]bool) error {
+	tokens, err := tokenizeExpression(expression)
+	if err != nil {
+		return fmt.Errorf("invalid partition expression %q: %w", expression, err)
+	}
+	p := &expressionParser{tokens: tokens, keys: keys}
+	err = p.parseOr()
+	if err == nil && !p.done() {
+		err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid partition expression %q: %w", expression, err)
+	}
+	return nil
+}
+
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenPunct
+	tokenEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenizeExpression(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		r := rune(s[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, token{tokenPunct, string(r)})
+			i++
+		case strings.ContainsRune("=<>!", r):
+			j := i + 1
+			for j < len(s) && strings.ContainsRune("=<>", rune(s[j])) {
+				j++
+			}
+			op := s[i:j]
+			switch op {
+			case "=", "<", ">", "<=", ">=", "<>", "!=":
+			default:
+				return nil, fmt.Errorf("unknown operator %q", op)
+			}
+			tokens = append(tokens, token{tokenOperator, op})
+			i = j
+		case r == '\'':
+			j := i + 1
+			for {
+				if j >= len(s) {
+					return nil, fmt.Errorf("unterminated string starting at %d", i)
+				}
+				if s[j] == '\'' {
+					if j+1 < len(s) && s[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			tokens = append(tokens, token{tokenString, s[i : j+1]})
+			i = j + 1
+		case r == '`':
+			j := strings.IndexByte(s[i+1:], '`')
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated identifier starting at %d", i)
+			}
+			tokens = append(tokens, token{tokenIdent, s[i+1 : i+1+j]})
+			i += j + 2
+		case unicode.IsDigit(r) || r == '-' || r == '.':
+			j := i + 1
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, s[i:j]})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdent, s[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", r, i)
+		}
+	}
+	return tokens, nil
+}
+
+type expressionParser struct {
+	tokens []token
+	pos    int
+	keys   map[string]bool
+}
+
+func (p *expressionParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *expressionParser) peek() token {
+	if p.done() {
+		return token{kind: tokenEOF, text: "end of expression"}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() token {
+	t := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token when it is the case-insensitive keyword
+// word.
+func (p *expressionParser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tokenIdent && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *expressionParser) punct(text string) error {
+	t := p.next()
+	if t.kind != tokenPunct || t.text != text {
+		return fmt.Errorf("expected %q, got %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *expressionParser) parseOr() error {
+	if err := p.parseAnd(); err != nil {
+		return err
+	}
+	for p.keyword("OR") {
+		if err := p.parseAnd(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *expressionParser) parseAnd() error {
+	if err := p.parseNot(); err != nil {
+		return err
+	}
+	for p.keyword("AND") {
+		if err := p.parseNot(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *expressionParser) parseNot() error {
+	if p.keyword("NOT") {
+		return p.parseNot()
+	}
+	if t := p.peek(); t.kind == tokenPunct && t.text == "(" {
+		p.next()
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+		return p.punct(")")
+	}
+	return p.parseComparison()
+}
+
+func (p *expressionParser) parseComparison() error {
+	column := p.next()
+	if column.kind != tokenIdent || isExpressionKeyword(column.text) {
+		return fmt.Errorf("expected partition key, got %q", column.text)
+	}
+	if p.keys != nil && !p.keys[strings.ToLower(column.text)] {
+		return fmt.Errorf("%q is not a partition key", column.text)
+	}
+	if t := p.peek(); t.kind == tokenOperator {
+		p.next()
+		return p.parseLiteral()
+	}
+	switch {
+	case p.keyword("BETWEEN"):
+		if err := p.parseLiteral(); err != nil {
+			return err
+		}
+		if !p.keyword("AND") {
+			return fmt.Errorf("expected AND in BETWEEN, got %q", p.peek().text)
+		}
+		return p.parseLiteral()
+	case p.keyword("IS"):
+		p.keyword("NOT")
+		if !p.keyword("NULL") {
+			return fmt.Errorf("expected NULL, got %q", p.peek().text)
+		}
+		return nil
+	}
+	p.keyword("NOT")
+	switch {
+	case p.keyword("LIKE"):
+		return p.parseLiteral()
+	case p.keyword("IN"):
+		if err := p.punct("("); err != nil {
+			return err
+		}
+		for {
+			if err := p.parseLiteral(); err != nil {
+				return err
+			}
+			if t := p.peek(); t.kind == tokenPunct && t.text == "," {
+				p.next()
+				continue
+			}
+			return p.punct(")")
+		}
+	}
+	return fmt.Errorf("expected comparison after %q, got %q", column.text, p.peek().text)
+}
+
+func (p *expressionParser) parseLiteral() error {
+	t := p.next()
+	if t.kind != tokenString && t.kind != tokenNumber {
+		return fmt.Errorf("expected value, got %q", t.text)
+	}
+	return nil
+}
+
+func isExpressionKeyword(word string) bool {
+	switch strings.ToUpper(word) {
+	case "AND", "OR", "NOT", "BETWEEN", "IN", "LIKE", "IS", "NULL":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package elmercrawl
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+func TestValidatePartitionExpression(t *testing.T) {
+	keys := []*glue.Column{
+		{Name: aws.String("year")},
+		{Name: aws.String("month")},
+		{Name: aws.String("region")},
+	}
+	cases := []struct {
+		Expression string
+		Valid      bool
+	}{
+		{"year < 2023", true},
+		{"year = '2022' AND month >= '06'", true},
+		{"(year > 2020 OR region = 'us') AND NOT month = 1", true},
+		{"year BETWEEN 2020 AND 2022", true},
+		{"region IN ('us', 'eu''west')", true},
+		{"region NOT LIKE 'eu%'", true},
+		{"month IS NOT NULL", true},
+		{"`YEAR` <> 2023", true},
+		{"day = 1", false},
+		{"year <", false},
+		{"year = 2023 AND", false},
+		{"(year = 2023", false},
+		{"region = 'us", false},
+		{"year == 2023", false},
+		{"year = 2023 month = 1", false},
+		{"region IN ()", false},
+	}
+
+	for i, c := range cases {
+		err := ValidatePartitionExpression(c.Expression, keys)
+		if c.Valid && err != nil {
+			t.Fatalf("%d, expected %q to be valid, got %v", i, c.Expression, err)
+		}
+		if !c.Valid && err == nil {
+			t.Fatalf("%d, expected %q to be invalid", i, c.Expression)
+		}
+	}
+}
+
+type mockedExpressionGetPartitions struct {
+	mockedCatalog
+	expressions *[]string
+}
+
+func (m mockedExpressionGetPartitions) GetPartitionsWithContext(ctx aws.Context, in *glue.GetPartitionsInput, opts ...request.Option) (*glue.GetPartitionsOutput, error) {
+	*m.expressions = append(*m.expressions, aws.StringValue(in.Expression))
+	return m.mockedCatalog.GetPartitionsWithContext(ctx, in, opts...)
+}
+
+func TestCrawlPartitionsExpression(t *testing.T) {
+	cases := []struct {
+		Expression string
+		Calls      int
+		Valid      bool
+	}{
+		{Expression: "logdate < 20220903", Calls: 2, Valid: true},
+		{Expression: "year < 2023", Calls: 0},
+		{Expression: "logdate <", Calls: 0},
+	}
+
+	for i, c := range cases {
+		catalog := newMockedCatalog(1, 1, 3)
+		catalog.Tables["testdb0"][0].PartitionKeys = []*glue.Column{{Name: aws.String("logdate")}}
+		var expressions []string
+		crawler := Crawler{
+			Glue:                mockedExpressionGetPartitions{mockedCatalog: catalog, expressions: &expressions},
+			PartitionExpression: c.Expression,
+		}
+		err := crawler.CrawlPartitions(func(p *glue.Partition) error { return nil })
+		if c.Valid && err != nil {
+			t.Fatalf("%d, unexpected error: %v", i, err)
+		}
+		if !c.Valid && err == nil {
+			t.Fatalf("%d, expected error", i)
+		}
+		if len(expressions) != c.Calls {
+			t.Fatalf("%d, expected %d GetPartitions calls, got %d", i, c.Calls, len(expressions))
+		}
+		for _, expression := range expressions {
+			if expression != c.Expression {
+				t.Fatalf("%d, expected expression %q, got %q", i, c.Expression, expression)
+			}
+		}
+	}
+}
+
+func TestCrawlPartitionsExpressionSkipsTables(t *testing.T) {
+	catalog := newMockedCatalog(1, 2, 3)
+	catalog.Tables["testdb0"][1].PartitionKeys = []*glue.Column{{Name: aws.String("logdate")}}
+	crawler := Crawler{Glue: catalog, PartitionExpression: "logdate < 20220903"}
+	for _, stream := range []bool{false, true} {
+		crawler.Stream = stream
+		var tables []string
+		err := crawler.CrawlPartitions(func(p *glue.Partition) error {
+			tables = append(tables, *p.TableName)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("stream %v, unexpected error: %v", stream, err)
+		}
+		if len(tables) != 3 || tables[0] != "testtable1" {
+			t.Fatalf("stream %v, expected only the partitions of testtable1, got %v", stream, tables)
+		}
+	}
+	it := crawler.Partitions(context.Background())
+	defer it.Close()
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if it.Err() != nil || n != 3 {
+		t.Fatalf("expected the iterator to skip testtable0, got %d partitions and %v", n, it.Err())
+	}
+}
+
+func TestCrawlPartitionsExpressionChange(t *testing.T) {
+	catalog := newMockedCatalog(1, 1, 3)
+	catalog.Tables["testdb0"][0].PartitionKeys = []*glue.Column{{Name: aws.String("logdate")}}
+	var expressions []string
+	crawler := Crawler{Glue: mockedExpressionGetPartitions{mockedCatalog: catalog, expressions: &expressions}}
+	cases := []struct {
+		Expression string
+		Listed     bool
+	}{
+		{Expression: "", Listed: true},
+		{Expression: "logdate < 20220903", Listed: true},
+		{Expression: "logdate < 20220903", Listed: false},
+		{Expression: "", Listed: true},
+	}
+	for i, c := range cases {
+		crawler.PartitionExpression = c.Expression
+		expressions = nil
+		err := crawler.CrawlPartitions(func(p *glue.Partition) error { return nil })
+		if err != nil {
+			t.Fatalf("%d, unexpected error: %v", i, err)
+		}
+		if (len(expressions) != 0) != c.Listed {
+			t.Fatalf("%d, expected partitions listed %v, got %d GetPartitions calls", i, c.Listed, len(expressions))
+		}
+		if c.Listed && expressions[0] != c.Expression {
+			t.Fatalf("%d, expected expression %q, got %q", i, c.Expression, expressions[0])
+		}
+	}
+}
+
+func TestCrawlPartitionsExpressionNoTableHasKeys(t *testing.T) {
+	catalog := newMockedCatalog(1, 2, 3)
+	catalog.Tables["testdb0"][1].PartitionKeys = []*glue.Column{{Name: aws.String("logdate")}}
+	crawler := Crawler{Glue: catalog, PartitionExpression: "logdat < 20220903"}
+	for _, stream := range []bool{false, true} {
+		crawler.Stream = stream
+		err := crawler.CrawlPartitions(func(p *glue.Partition) error {
+			t.Fatalf("stream %v, unexpected partition %v", stream, p)
+			return nil
+		})
+		if err == nil || !strings.Contains(err.Error(), "none of the 2 tables") {
+			t.Fatalf("stream %v, expected the misspelled key to be reported, got %v", stream, err)
+		}
+	}
+	it := crawler.Partitions(context.Background())
+	defer it.Close()
+	if it.Next() || it.Err() == nil {
+		t.Fatalf("expected the iterator to report the misspelled key, got %v", it.Err())
+	}
+}
@@ -0,0 +1,96 @@
+package elmercrawl
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// regexPrefix marks a filter pattern as a regular expression rather than a
+// glob.
+const regexPrefix = "re:"
+
+// NameFilter selects database or table names by include and exclude
+// patterns. Patterns are globs as understood by path.Match, or regular
+// expressions when prefixed with "re:". A name passes the filter when it
+// matches any include pattern, or there are none, and no exclude pattern.
+type NameFilter struct {
+	include []func(string) bool
+	exclude []func(string) bool
+}
+
+func NewNameFilter(include, exclude []string) (*NameFilter, error) {
+	f := &NameFilter{}
+	for _, pattern := range include {
+		match, err := compilePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("NewNameFilter failed to compile include pattern: %w", err)
+		}
+		f.include = append(f.include, match)
+	}
+	for _, pattern := range exclude {
+		match, err := compilePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("NewNameFilter failed to compile exclude pattern: %w", err)
+		}
+		f.exclude = append(f.exclude, match)
+	}
+	return f, nil
+}
+
+func compilePattern(pattern string) (func(string) bool, error) {
+	if strings.HasPrefix(pattern, regexPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(pattern, regexPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	return func(name string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}, nil
+}
+
+// Match reports whether name passes the filter. A nil filter passes every
+// name.
+func (f *NameFilter) Match(name string) bool {
+	if f == nil {
+		return true
+	}
+	for _, match := range f.exclude {
+		if match(name) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, match := range f.include {
+		if match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterNames returns the items of list whose name passes f, reusing list when
+// nothing is filtered out.
+func filterNames[T any](f *NameFilter, list []T, name func(T) *string) []T {
+	if f == nil {
+		return list
+	}
+	kept := list[:0:0]
+	for _, item := range list {
+		if f.Match(aws.StringValue(name(item))) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
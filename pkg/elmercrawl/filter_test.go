@@ -0,0 +1,116 @@
+package elmercrawl
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+func TestNameFilter(t *testing.T) {
+	cases := []struct {
+		Include  []string
+		Exclude  []string
+		Name     string
+		Expected bool
+	}{
+		{Name: "testdb", Expected: true},
+		{Include: []string{"test*"}, Name: "testdb", Expected: true},
+		{Include: []string{"prod*"}, Name: "testdb", Expected: false},
+		{Include: []string{"prod*", "re:^test.b$"}, Name: "testdb", Expected: true},
+		{Include: []string{"test*"}, Exclude: []string{"*db"}, Name: "testdb", Expected: false},
+		{Exclude: []string{"re:tmp_"}, Name: "events_tmp_1", Expected: false},
+		{Exclude: []string{"re:tmp_"}, Name: "events", Expected: true},
+	}
+
+	for i, c := range cases {
+		f, err := NewNameFilter(c.Include, c.Exclude)
+		if err != nil {
+			t.Fatalf("%d, unexpected error: %v", i, err)
+		}
+		if f.Match(c.Name) != c.Expected {
+			t.Fatalf("%d, expected match %t for %s", i, c.Expected, c.Name)
+		}
+	}
+
+	if _, err := NewNameFilter([]string{"re:("}, nil); err == nil {
+		t.Fatalf("expected invalid regular expression to fail")
+	}
+	if _, err := NewNameFilter(nil, []string{"[a-"}); err == nil {
+		t.Fatalf("expected invalid glob to fail")
+	}
+}
+
+type mockedCountingCatalog struct {
+	mockedCatalog
+	mu    *sync.Mutex
+	calls map[string]int
+}
+
+func (m mockedCountingCatalog) count(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls[key]++
+}
+
+func (m mockedCountingCatalog) GetTablesWithContext(ctx aws.Context, in *glue.GetTablesInput, opts ...request.Option) (*glue.GetTablesOutput, error) {
+	m.count("GetTables " + *in.DatabaseName)
+	return m.mockedCatalog.GetTablesWithContext(ctx, in, opts...)
+}
+
+func (m mockedCountingCatalog) GetPartitionsWithContext(ctx aws.Context, in *glue.GetPartitionsInput, opts ...request.Option) (*glue.GetPartitionsOutput, error) {
+	m.count("GetPartitions " + *in.DatabaseName + "." + *in.TableName)
+	return m.mockedCatalog.GetPartitionsWithContext(ctx, in, opts...)
+}
+
+func TestCrawlPartitionsFiltered(t *testing.T) {
+	dbFilter, err := NewNameFilter([]string{"testdb*"}, []string{"re:1$"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tableFilter, err := NewNameFilter([]string{"testtable0", "testtable2"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, stream := range []bool{false, true} {
+		catalog := mockedCountingCatalog{
+			mockedCatalog: newMockedCatalog(3, 3, 1),
+			mu:            &sync.Mutex{},
+			calls:         map[string]int{},
+		}
+		crawler := Crawler{
+			Glue:           catalog,
+			Stream:         stream,
+			DatabaseFilter: dbFilter,
+			TableFilter:    tableFilter,
+		}
+		var got []string
+		err = crawler.CrawlPartitions(func(p *glue.Partition) error {
+			got = append(got, *p.DatabaseName+"."+*p.TableName)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("stream %t, unexpected error: %v", stream, err)
+		}
+		expected := []string{"testdb0.testtable0", "testdb0.testtable2", "testdb2.testtable0", "testdb2.testtable2"}
+		if len(got) != len(expected) {
+			t.Fatalf("stream %t, expected %v, got %v", stream, expected, got)
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Fatalf("stream %t, expected %v, got %v", stream, expected, got)
+			}
+		}
+		if catalog.calls["GetTables testdb1"] != 0 {
+			t.Fatalf("stream %t, expected filtered database not to be listed for tables", stream)
+		}
+		if catalog.calls["GetPartitions testdb0.testtable1"] != 0 {
+			t.Fatalf("stream %t, expected filtered table not to be listed for partitions", stream)
+		}
+		if len(catalog.calls) != 6 {
+			t.Fatalf("stream %t, expected 6 distinct listing calls, got %v", stream, catalog.calls)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package elmercrawl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type glueFunctionFunc func(*glue.UserDefinedFunction) error
+
+func (c *Crawler) CrawlUserDefinedFunctions(gff glueFunctionFunc) error {
+	return c.CrawlUserDefinedFunctionsWithContext(context.Background(), gff)
+}
+
+func (c *Crawler) CrawlUserDefinedFunctionsWithContext(ctx context.Context, gff glueFunctionFunc) error {
+	var failures *errorCollector
+	if c.KeepGoing {
+		failures = c.newErrorCollector()
+		gff = failures.functionFunc(gff)
+	}
+	if c.Stream {
+		err := c.streamUserDefinedFunctions(ctx, gff)
+		if err != nil {
+			return fmt.Errorf("CrawlUserDefinedFunctions failed to stream functions: %w", err)
+		}
+		return failures.err()
+	}
+	err := c.loadUserDefinedFunctions(ctx)
+	if err != nil {
+		return fmt.Errorf("CrawlUserDefinedFunctions failed to get functions: %w", err)
+	}
+	err = callEach(ctx, c.functions, c.Parallel, nil, gff)
+	if err != nil {
+		return fmt.Errorf("CrawlUserDefinedFunctions failed to run function: %w", err)
+	}
+	return failures.err()
+}
+
+func (c *Crawler) getUserDefinedFunctions(ctx context.Context) error {
+	err := c.loadDatabases(ctx)
+	if err != nil {
+		return fmt.Errorf("getUserDefinedFunctions failed to get databases: %w", err)
+	}
+	functions, err := collectChildren(ctx, c.databases, c.Workers, c.walkUserDefinedFunctionPages)
+	if err != nil {
+		return fmt.Errorf("getUserDefinedFunctions failed to get functions: %w", err)
+	}
+	c.functions = functions
+	c.functionsAt = time.Now()
+	return nil
+}
+
+func (c *Crawler) streamUserDefinedFunctions(ctx context.Context, gff glueFunctionFunc) error {
+	sem := newSemaphore(c.Parallel)
+	return c.streamEachDatabase(ctx, func(ctx context.Context, db *glue.Database) error {
+		return c.walkUserDefinedFunctionPages(ctx, db, func(page []*glue.UserDefinedFunction) error {
+			err := callEach(ctx, page, c.Parallel, sem, gff)
+			if err != nil {
+				return fmt.Errorf("streamUserDefinedFunctions failed to run function: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+func (c *Crawler) walkUserDefinedFunctionPages(ctx context.Context, db *glue.Database, pagef func([]*glue.UserDefinedFunction) error) error {
+	return newIterator(ctx, c.userDefinedFunctionPages(db).nextPage).eachPage(pagef)
+}
+
+// userDefinedFunctionPages pages through the functions of db.
+func (c *Crawler) userDefinedFunctionPages(db *glue.Database) *pager[*glue.UserDefinedFunction] {
+	return &pager[*glue.UserDefinedFunction]{fetch: func(ctx context.Context, token *string) ([]*glue.UserDefinedFunction, *string, error) {
+		input := &glue.GetUserDefinedFunctionsInput{
+			CatalogId:    c.glueCatalogId(),
+			DatabaseName: db.Name,
+			Pattern:      aws.String("*"),
+			NextToken:    token,
+		}
+		var getFnOut *glue.GetUserDefinedFunctionsOutput
+		err := c.call(ctx, "GetUserDefinedFunctions", func() (err error) {
+			getFnOut, err = c.Glue.GetUserDefinedFunctionsWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("userDefinedFunctionPages failed to get functions for database %s: %w", aws.StringValue(db.Name), err)
+		}
+		return getFnOut.UserDefinedFunctions, getFnOut.NextToken, nil
+	}}
+}
@@ -0,0 +1,54 @@
+package elmercrawl
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type mockedGetUserDefinedFunctions struct {
+	mockedCatalog
+	Functions int
+}
+
+func (m mockedGetUserDefinedFunctions) GetUserDefinedFunctionsWithContext(_ aws.Context, in *glue.GetUserDefinedFunctionsInput, _ ...request.Option) (*glue.GetUserDefinedFunctionsOutput, error) {
+	if aws.StringValue(in.Pattern) == "" {
+		return nil, errors.New("mocked missing pattern")
+	}
+	var functions []*glue.UserDefinedFunction
+	for i := 0; i < m.Functions; i++ {
+		functions = append(functions, &glue.UserDefinedFunction{
+			DatabaseName: in.DatabaseName,
+			FunctionName: aws.String(fmt.Sprintf("udf%d", i)),
+		})
+	}
+	start, end, next := m.page(in.NextToken, len(functions))
+	return &glue.GetUserDefinedFunctionsOutput{UserDefinedFunctions: functions[start:end], NextToken: next}, nil
+}
+
+func TestCrawlUserDefinedFunctions(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		crawler := Crawler{
+			Glue:   mockedGetUserDefinedFunctions{mockedCatalog: newMockedCatalog(3, 0, 0), Functions: 3},
+			Stream: stream,
+		}
+		var got []string
+		err := crawler.CrawlUserDefinedFunctions(func(f *glue.UserDefinedFunction) error {
+			got = append(got, *f.DatabaseName+"."+*f.FunctionName)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("stream %t, unexpected error: %v", stream, err)
+		}
+		if len(got) != 9 {
+			t.Fatalf("stream %t, expected 9 functions, got %d", stream, len(got))
+		}
+		if got[0] != "testdb0.udf0" || got[8] != "testdb2.udf2" {
+			t.Fatalf("stream %t, unexpected function order: first %s, last %s", stream, got[0], got[8])
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package elmercrawl
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// IncrementalState is what an incremental crawl remembers between runs,
+// separately for tables and partitions.
+type IncrementalState struct {
+	Tables     IncrementalLevel
+	Partitions IncrementalLevel
+}
+
+// IncrementalLevel is the state of incremental crawls of one level.
+type IncrementalLevel struct {
+	// Since is when the last run started, or listed the cached objects it
+	// crawled, less incrementalClockSkew. Objects with no update, creation
+	// or last access time after it are skipped by the next run. A zero Since
+	// crawls every object.
+	Since time.Time
+	// Keys are the objects seen by the last run, as database.table or
+	// database.table/value/... for partitions.
+	Keys []string
+	// Deleted are the keys seen by the previous run but not by the last one.
+	Deleted []string `json:"-"`
+}
+
+// incrementalClockSkew is how much earlier than the start of a run the next
+// run's mark is set, so that objects updated while the run listed them are
+// crawled again even when Glue's clock is somewhat behind ours.
+const incrementalClockSkew = 5 * time.Minute
+
+// incrementalRun tracks one incremental crawl of a level. Its methods do
+// nothing on a nil run so crawls without Incremental need no checks.
+type incrementalRun struct {
+	level *IncrementalLevel
+	mu    sync.Mutex
+	since time.Time
+	seen  map[string]bool
+}
+
+// newIncrementalRun starts an incremental crawl of level. Its start, rather
+// than the newest time of the objects seen, marks the next run's Since.
+func newIncrementalRun(level *IncrementalLevel) *incrementalRun {
+	return &incrementalRun{level: level, since: time.Now().Add(-incrementalClockSkew), seen: map[string]bool{}}
+}
+
+// listedAt moves the next run's mark back to when the objects crawled were
+// listed, for crawls served from the cache, so that objects updated since
+// are crawled by the next run.
+func (r *incrementalRun) listedAt(listed time.Time) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if since := listed.Add(-incrementalClockSkew); since.Before(r.since) {
+		r.since = since
+	}
+}
+
+// changed records key as seen and reports whether any of times is after the
+// previous run's mark.
+func (r *incrementalRun) changed(key string, times ...*time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen[key] = true
+	if r.level.Since.IsZero() {
+		return true
+	}
+	for _, t := range times {
+		if t != nil && t.After(r.level.Since) {
+			return true
+		}
+	}
+	return false
+}
+
+// finish stores the new mark, the keys seen and the keys deleted since the
+// previous run when the crawl ended with err nil. A failed crawl leaves the
+// state untouched so the next run crawls the same objects again.
+func (r *incrementalRun) finish(err error) error {
+	if r == nil || err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(r.seen))
+	for key := range r.seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var deleted []string
+	for _, key := range r.level.Keys {
+		if !r.seen[key] {
+			deleted = append(deleted, key)
+		}
+	}
+	r.level.Since = r.since
+	r.level.Keys = keys
+	r.level.Deleted = deleted
+	return nil
+}
+
+func (r *incrementalRun) tableFunc(gtf glueTableFunc) glueTableFunc {
+	return func(table *glue.TableData) error {
+		key := aws.StringValue(table.DatabaseName) + "." + aws.StringValue(table.Name)
+		if !r.changed(key, table.UpdateTime, table.CreateTime, table.LastAccessTime) {
+			return nil
+		}
+		return gtf(table)
+	}
+}
+
+func (r *incrementalRun) partitionFunc(tpf tablePartitionFunc) tablePartitionFunc {
+	return func(table *glue.TableData, partition *glue.Partition) error {
+		key := aws.StringValue(partition.DatabaseName) + "." + aws.StringValue(partition.TableName) + "/" + strings.Join(aws.StringValueSlice(partition.Values), "/")
+		if !r.changed(key, partition.CreationTime, partition.LastAccessTime) {
+			return nil
+		}
+		return tpf(table, partition)
+	}
+}
@@ -0,0 +1,124 @@
+package elmercrawl
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+func TestCrawlTablesIncremental(t *testing.T) {
+	start := time.Now().Add(-24 * time.Hour)
+	catalog := newMockedCatalog(1, 3, 0)
+	for i, table := range catalog.Tables["testdb0"] {
+		table.UpdateTime = aws.Time(start.Add(time.Duration(i) * time.Hour))
+	}
+	state := &IncrementalState{}
+	crawl := func(stream bool) []string {
+		crawler := Crawler{Glue: &catalog, Incremental: state, Stream: stream}
+		var got []string
+		err := crawler.CrawlTables(func(table *glue.TableData) error {
+			got = append(got, *table.Name)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return got
+	}
+
+	before := time.Now()
+	if got := crawl(false); len(got) != 3 {
+		t.Fatalf("expected the first run to crawl 3 tables, got %v", got)
+	}
+	since := state.Tables.Since.Add(incrementalClockSkew)
+	if since.Before(before) || since.After(time.Now()) || len(state.Tables.Keys) != 3 {
+		t.Fatalf("unexpected state after the first run: %v %v", state.Tables.Since, state.Tables.Keys)
+	}
+	if got := crawl(true); len(got) != 0 {
+		t.Fatalf("expected an unchanged catalog to crawl no tables, got %v", got)
+	}
+
+	catalog.Tables["testdb0"] = catalog.Tables["testdb0"][1:]
+	catalog.Tables["testdb0"][0].UpdateTime = aws.Time(time.Now())
+	got := crawl(false)
+	if len(got) != 1 || got[0] != "testtable1" {
+		t.Fatalf("expected only testtable1 to be crawled, got %v", got)
+	}
+	if len(state.Tables.Deleted) != 1 || state.Tables.Deleted[0] != "testdb0.testtable0" {
+		t.Fatalf("expected testtable0 to be reported deleted, got %v", state.Tables.Deleted)
+	}
+}
+
+func TestCrawlTablesIncrementalUpdatedDuringCrawl(t *testing.T) {
+	catalog := newMockedCatalog(1, 3, 0)
+	for _, table := range catalog.Tables["testdb0"] {
+		table.UpdateTime = aws.Time(time.Now().Add(-24 * time.Hour))
+	}
+	state := &IncrementalState{}
+	crawler := Crawler{Glue: &catalog, Incremental: state, Stream: true}
+	err := crawler.CrawlTables(func(table *glue.TableData) error {
+		// testtable0 is updated after it was listed, and testtable2, on the
+		// next page, after that.
+		if *table.Name == "testtable0" {
+			catalog.Tables["testdb0"][0] = &glue.TableData{DatabaseName: table.DatabaseName, Name: table.Name, UpdateTime: aws.Time(time.Now())}
+			catalog.Tables["testdb0"][2].UpdateTime = aws.Time(time.Now().Add(time.Second))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	err = crawler.CrawlTables(func(table *glue.TableData) error {
+		got = append(got, *table.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "testtable0" || got[1] != "testtable2" {
+		t.Fatalf("expected the tables updated during the first run to be crawled, got %v", got)
+	}
+}
+
+func TestCrawlPartitionsIncrementalFailureKeepsState(t *testing.T) {
+	catalog := newMockedCatalog(1, 1, 2)
+	state := &IncrementalState{}
+	crawler := Crawler{Glue: catalog, Incremental: state, KeepGoing: true}
+	err := crawler.CrawlPartitions(func(p *glue.Partition) error { return errors.New("boom") })
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !state.Partitions.Since.IsZero() || state.Partitions.Keys != nil {
+		t.Fatalf("expected a failed crawl to leave the state untouched, got %v", state.Partitions)
+	}
+}
+
+func TestCrawlTablesIncrementalCached(t *testing.T) {
+	catalog := newMockedCatalog(1, 2, 0)
+	for _, table := range catalog.Tables["testdb0"] {
+		table.UpdateTime = aws.Time(time.Now().Add(-24 * time.Hour))
+	}
+	crawler := Crawler{Glue: &catalog}
+	crawledTables(t, &crawler)
+	// The cache was filled two hours ago, and testtable1 updated since.
+	crawler.tablesAt = time.Now().Add(-2 * time.Hour)
+	catalog.Tables["testdb0"][1] = &glue.TableData{
+		DatabaseName: aws.String("testdb0"),
+		Name:         aws.String("testtable1"),
+		UpdateTime:   aws.Time(time.Now().Add(-time.Hour)),
+	}
+
+	crawler.Incremental = &IncrementalState{}
+	if got := crawledTables(t, &crawler); len(got) != 2 {
+		t.Fatalf("expected the cached run to crawl 2 tables, got %v", got)
+	}
+	crawler.Invalidate(TableCache)
+	got := crawledTables(t, &crawler)
+	if len(got) != 1 || got[0] != "testdb0.testtable1" {
+		t.Fatalf("expected the table updated after the cache was filled to be crawled, got %v", got)
+	}
+}
@@ -0,0 +1,190 @@
+package elmercrawl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// pager fetches the pages of one Glue listing on demand.
+type pager[T any] struct {
+	fetch func(ctx context.Context, token *string) ([]T, *string, error)
+	token *string
+	done  bool
+}
+
+// nextPage fetches the next page, returning false once the listing is
+// exhausted.
+func (p *pager[T]) nextPage(ctx context.Context) ([]T, bool, error) {
+	if p.done {
+		return nil, false, nil
+	}
+	page, token, err := p.fetch(ctx, p.token)
+	if err != nil {
+		p.done = true
+		return nil, false, err
+	}
+	p.token = token
+	p.done = token == nil
+	return page, true, nil
+}
+
+// Iterator pages through crawled objects as they are asked for, fetching the
+// next page from Glue only once the current one is used up:
+//
+//	it := crawler.Tables(ctx)
+//	defer it.Close()
+//	for it.Next() {
+//		table := it.Value()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// Iterators honor the crawler's filters, partition expression, rate limiter
+// and retry policy, but list one database or table at a time and never read
+// or fill the crawler's caches. An Iterator is not safe for concurrent use.
+type Iterator[T any] struct {
+	ctx    context.Context
+	pages  func(context.Context) ([]T, bool, error)
+	page   []T
+	value  T
+	err    error
+	closed bool
+}
+
+func newIterator[T any](ctx context.Context, pages func(context.Context) ([]T, bool, error)) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, pages: pages}
+}
+
+// Next advances to the next object, returning false when there are no more
+// objects, the iterator was closed or a request failed.
+func (it *Iterator[T]) Next() bool {
+	for len(it.page) == 0 {
+		page, ok := it.nextPage()
+		if !ok {
+			return false
+		}
+		it.page = page
+	}
+	it.value, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// nextPage fetches the next page, returning false once the iterator is
+// exhausted, closed or failed.
+func (it *Iterator[T]) nextPage() ([]T, bool) {
+	if it.closed || it.err != nil {
+		return nil, false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return nil, false
+	}
+	page, ok, err := it.pages(it.ctx)
+	if err != nil {
+		it.err = err
+		return nil, false
+	}
+	if !ok {
+		it.closed = true
+		return nil, false
+	}
+	return page, true
+}
+
+// eachPage passes the remaining objects to pagef a page at a time and closes
+// the iterator. The callback crawls are built on it.
+func (it *Iterator[T]) eachPage(pagef func([]T) error) error {
+	defer it.Close()
+	page, ok := it.page, len(it.page) != 0
+	if !ok {
+		page, ok = it.nextPage()
+	}
+	for ok {
+		err := pagef(page)
+		if err != nil {
+			return err
+		}
+		page, ok = it.nextPage()
+	}
+	return it.err
+}
+
+// Value returns the object Next advanced to.
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Err returns the error that stopped the iterator, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close stops the iterator. Later calls to Next return false without
+// sending any more requests.
+func (it *Iterator[T]) Close() error {
+	it.closed = true
+	it.page = nil
+	return nil
+}
+
+// Databases returns an iterator over the databases of the catalog.
+func (c *Crawler) Databases(ctx context.Context) *Iterator[*glue.Database] {
+	return newIterator(ctx, c.databasePages().nextPage)
+}
+
+// Tables returns an iterator over the tables of every database.
+func (c *Crawler) Tables(ctx context.Context) *Iterator[*glue.TableData] {
+	databases := c.Databases(ctx)
+	var tables *pager[*glue.TableData]
+	return newIterator(ctx, func(ctx context.Context) ([]*glue.TableData, bool, error) {
+		for tables == nil || tables.done {
+			if !databases.Next() {
+				return nil, false, databases.Err()
+			}
+			tables = c.tablePages(databases.Value())
+		}
+		return tables.nextPage(ctx)
+	})
+}
+
+// Partitions returns an iterator over the partitions of every table. Tables
+// are not split into PartitionSegments.
+func (c *Crawler) Partitions(ctx context.Context) *Iterator[*glue.Partition] {
+	tables := c.Tables(ctx)
+	var (
+		partitions *pager[*glue.Partition]
+		tally      *expressionTally
+	)
+	if c.PartitionExpression != "" {
+		tally = &expressionTally{}
+	}
+	return newIterator(ctx, func(ctx context.Context) ([]*glue.Partition, bool, error) {
+		for partitions == nil || partitions.done {
+			if !tables.Next() {
+				if err := tables.Err(); err != nil {
+					return nil, false, err
+				}
+				if err := tally.err(c.PartitionExpression); err != nil {
+					return nil, false, fmt.Errorf("Partitions failed to match expression: %w", err)
+				}
+				return nil, false, nil
+			}
+			table := tables.Value()
+			expression, ok, err := c.tableExpression(table)
+			if err != nil {
+				return nil, false, fmt.Errorf("Partitions failed to list table %s.%s: %w", aws.StringValue(table.DatabaseName), aws.StringValue(table.Name), err)
+			}
+			tally.add(ok)
+			if !ok {
+				continue
+			}
+			partitions = c.partitionPages(table, expression, nil)
+		}
+		return partitions.nextPage(ctx)
+	})
+}
@@ -0,0 +1,128 @@
+package elmercrawl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type mockedFailingPartitions struct {
+	mockedCatalog
+	failTable string
+}
+
+func (m mockedFailingPartitions) GetPartitionsWithContext(ctx aws.Context, in *glue.GetPartitionsInput, opts ...request.Option) (*glue.GetPartitionsOutput, error) {
+	if *in.TableName == m.failTable {
+		return nil, errors.New("mocked failure")
+	}
+	return m.mockedCatalog.GetPartitionsWithContext(ctx, in, opts...)
+}
+
+func newCountingCatalog(databases, tablesPerDatabase, partitionsPerTable int) mockedCountingCatalog {
+	return mockedCountingCatalog{
+		mockedCatalog: newMockedCatalog(databases, tablesPerDatabase, partitionsPerTable),
+		mu:            &sync.Mutex{},
+		calls:         map[string]int{},
+	}
+}
+
+func (m mockedCountingCatalog) total(prefix string) int {
+	n := 0
+	for key, calls := range m.calls {
+		if strings.HasPrefix(key, prefix) {
+			n += calls
+		}
+	}
+	return n
+}
+
+func TestPartitionsIterator(t *testing.T) {
+	catalog := newCountingCatalog(2, 2, 5)
+	crawler := Crawler{Glue: catalog}
+	it := crawler.Partitions(context.Background())
+	var got []string
+	for it.Next() {
+		p := it.Value()
+		got = append(got, *p.DatabaseName+"."+*p.TableName+"/"+*p.Values[0])
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 20 {
+		t.Fatalf("expected 20 partitions, got %d", len(got))
+	}
+	if got[0] != "testdb0.testtable0/20220900" || got[19] != "testdb1.testtable1/20220904" {
+		t.Fatalf("unexpected partition order: first %s, last %s", got[0], got[19])
+	}
+	if requests := catalog.total("GetPartitions "); requests != 12 {
+		t.Fatalf("expected 12 GetPartitions requests, got %d", requests)
+	}
+	if crawler.partitions != nil {
+		t.Fatalf("expected iterator not to populate the partition cache")
+	}
+}
+
+func TestPartitionsIteratorIsLazy(t *testing.T) {
+	catalog := newCountingCatalog(2, 2, 5)
+	crawler := Crawler{Glue: catalog}
+	it := crawler.Partitions(context.Background())
+	for i := 0; i < 3; i++ {
+		if !it.Next() {
+			t.Fatalf("expected partition %d, got none: %v", i, it.Err())
+		}
+	}
+	it.Close()
+	if it.Next() {
+		t.Fatalf("expected no partitions after Close")
+	}
+	if requests := catalog.total("GetPartitions "); requests != 2 {
+		t.Fatalf("expected 2 GetPartitions requests for 3 partitions, got %d", requests)
+	}
+}
+
+func TestPartitionsIteratorError(t *testing.T) {
+	crawler := Crawler{Glue: mockedFailingPartitions{mockedCatalog: newMockedCatalog(2, 2, 1), failTable: "testtable1"}}
+	it := crawler.Partitions(context.Background())
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if it.Err() == nil {
+		t.Fatalf("expected error")
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 partition before the failure, got %d", n)
+	}
+}
+
+func TestIteratorEachPage(t *testing.T) {
+	crawler := Crawler{Glue: newMockedCatalog(5, 0, 0)}
+	it := crawler.Databases(context.Background())
+	if !it.Next() || *it.Value().Name != "testdb0" {
+		t.Fatalf("expected testdb0 first")
+	}
+	var pages [][]string
+	err := it.eachPage(func(page []*glue.Database) error {
+		var names []string
+		for _, db := range page {
+			names = append(names, *db.Name)
+		}
+		pages = append(pages, names)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pages) != 3 || len(pages[0]) != 1 || pages[0][0] != "testdb1" || pages[2][0] != "testdb4" {
+		t.Fatalf("expected the rest of the first page and two more pages, got %v", pages)
+	}
+	if it.Next() {
+		t.Fatalf("expected eachPage to close the iterator")
+	}
+}
@@ -0,0 +1,102 @@
+package elmercrawl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// PartitionIndex is a partition index together with the table it belongs to.
+type PartitionIndex struct {
+	Table *glue.TableData
+	Index *glue.PartitionIndexDescriptor
+}
+
+type gluePartitionIndexFunc func(*PartitionIndex) error
+
+func (c *Crawler) CrawlPartitionIndexes(gpif gluePartitionIndexFunc) error {
+	return c.CrawlPartitionIndexesWithContext(context.Background(), gpif)
+}
+
+func (c *Crawler) CrawlPartitionIndexesWithContext(ctx context.Context, gpif gluePartitionIndexFunc) error {
+	var failures *errorCollector
+	if c.KeepGoing {
+		failures = c.newErrorCollector()
+		gpif = failures.partitionIndexFunc(gpif)
+	}
+	if c.Stream {
+		err := c.streamPartitionIndexes(ctx, gpif)
+		if err != nil {
+			return fmt.Errorf("CrawlPartitionIndexes failed to stream partition indexes: %w", err)
+		}
+		return failures.err()
+	}
+	err := c.loadPartitionIndexes(ctx)
+	if err != nil {
+		return fmt.Errorf("CrawlPartitionIndexes failed to get partition indexes: %w", err)
+	}
+	err = callEach(ctx, c.partitionIndexes, c.Parallel, nil, gpif)
+	if err != nil {
+		return fmt.Errorf("CrawlPartitionIndexes failed to run function: %w", err)
+	}
+	return failures.err()
+}
+
+func (c *Crawler) getPartitionIndexes(ctx context.Context) error {
+	err := c.loadTables(ctx)
+	if err != nil {
+		return fmt.Errorf("getPartitionIndexes failed to get tables: %w", err)
+	}
+	indexes, err := collectChildren(ctx, c.tables, c.Workers, c.walkPartitionIndexPages)
+	if err != nil {
+		return fmt.Errorf("getPartitionIndexes failed to get partition indexes: %w", err)
+	}
+	c.partitionIndexes = indexes
+	c.partitionIndexesAt = time.Now()
+	return nil
+}
+
+func (c *Crawler) streamPartitionIndexes(ctx context.Context, gpif gluePartitionIndexFunc) error {
+	sem := newSemaphore(c.Parallel)
+	return c.streamEachTable(ctx, func(ctx context.Context, table *glue.TableData) error {
+		return c.walkPartitionIndexPages(ctx, table, func(page []*PartitionIndex) error {
+			err := callEach(ctx, page, c.Parallel, sem, gpif)
+			if err != nil {
+				return fmt.Errorf("streamPartitionIndexes failed to run function: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+func (c *Crawler) walkPartitionIndexPages(ctx context.Context, table *glue.TableData, pagef func([]*PartitionIndex) error) error {
+	return newIterator(ctx, c.partitionIndexPages(table).nextPage).eachPage(pagef)
+}
+
+// partitionIndexPages pages through the partition indexes of table.
+func (c *Crawler) partitionIndexPages(table *glue.TableData) *pager[*PartitionIndex] {
+	return &pager[*PartitionIndex]{fetch: func(ctx context.Context, token *string) ([]*PartitionIndex, *string, error) {
+		input := &glue.GetPartitionIndexesInput{
+			CatalogId:    c.glueCatalogId(),
+			DatabaseName: table.DatabaseName,
+			TableName:    table.Name,
+			NextToken:    token,
+		}
+		var getIdxOut *glue.GetPartitionIndexesOutput
+		err := c.call(ctx, "GetPartitionIndexes", func() (err error) {
+			getIdxOut, err = c.Glue.GetPartitionIndexesWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("partitionIndexPages failed to get partition indexes for table %s.%s: %w", aws.StringValue(table.DatabaseName), aws.StringValue(table.Name), err)
+		}
+		page := make([]*PartitionIndex, len(getIdxOut.PartitionIndexDescriptorList))
+		for i, index := range getIdxOut.PartitionIndexDescriptorList {
+			page[i] = &PartitionIndex{Table: table, Index: index}
+		}
+		return page, getIdxOut.NextToken, nil
+	}}
+}
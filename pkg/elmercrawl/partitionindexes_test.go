@@ -0,0 +1,46 @@
+package elmercrawl
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type mockedGetPartitionIndexes struct {
+	mockedCatalog
+}
+
+func (m mockedGetPartitionIndexes) GetPartitionIndexesWithContext(_ aws.Context, in *glue.GetPartitionIndexesInput, _ ...request.Option) (*glue.GetPartitionIndexesOutput, error) {
+	indexes := []*glue.PartitionIndexDescriptor{
+		{IndexName: aws.String(*in.TableName + "_idx1")},
+		{IndexName: aws.String(*in.TableName + "_idx2")},
+		{IndexName: aws.String(*in.TableName + "_idx3")},
+	}
+	start, end, next := m.page(in.NextToken, len(indexes))
+	return &glue.GetPartitionIndexesOutput{PartitionIndexDescriptorList: indexes[start:end], NextToken: next}, nil
+}
+
+func TestCrawlPartitionIndexes(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		crawler := Crawler{
+			Glue:   mockedGetPartitionIndexes{mockedCatalog: newMockedCatalog(2, 2, 0)},
+			Stream: stream,
+		}
+		var got []string
+		err := crawler.CrawlPartitionIndexes(func(index *PartitionIndex) error {
+			got = append(got, *index.Table.DatabaseName+"."+*index.Index.IndexName)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("stream %t, unexpected error: %v", stream, err)
+		}
+		if len(got) != 12 {
+			t.Fatalf("stream %t, expected 12 partition indexes, got %d", stream, len(got))
+		}
+		if got[0] != "testdb0.testtable0_idx1" || got[11] != "testdb1.testtable1_idx3" {
+			t.Fatalf("stream %t, unexpected partition index order: first %s, last %s", stream, got[0], got[11])
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package elmercrawl
+
+import (
+	"context"
+	"sync"
+)
+
+// forEach calls fn for every index in [0, n) using up to workers goroutines.
+// The first error cancels the context handed to the remaining calls and is
+// returned once every started call has finished.
+func forEach(ctx context.Context, n, workers int, fn func(context.Context, int) error) error {
+	if workers <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(ctx, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if workers > n {
+		workers = n
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	indexes := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(ctx, i); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// newSemaphore returns a channel that admits at most n holders, treating
+// anything below one as one.
+func newSemaphore(n int) chan struct{} {
+	if n < 1 {
+		n = 1
+	}
+	return make(chan struct{}, n)
+}
+
+// callEach calls fn for every item on up to parallel goroutines. When sem is
+// not nil each call also holds a slot in it, which bounds the number of calls
+// running across several callEach invocations.
+func callEach[T any](ctx context.Context, items []T, parallel int, sem chan struct{}, fn func(T) error) error {
+	return forEach(ctx, len(items), parallel, func(ctx context.Context, i int) error {
+		if sem != nil {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() { <-sem }()
+		}
+		return fn(items[i])
+	})
+}
@@ -0,0 +1,71 @@
+package elmercrawl
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	for _, workers := range []int{0, 1, 4, 100} {
+		seen := make([]int32, 50)
+		err := forEach(context.Background(), len(seen), workers, func(_ context.Context, i int) error {
+			atomic.AddInt32(&seen[i], 1)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%d workers, unexpected error: %v", workers, err)
+		}
+		for i := range seen {
+			if seen[i] != 1 {
+				t.Fatalf("%d workers, expected index %d to be visited once, got %d", workers, i, seen[i])
+			}
+		}
+	}
+}
+
+func TestForEachFirstError(t *testing.T) {
+	errBoom := errors.New("boom")
+	for _, workers := range []int{1, 4} {
+		var calls int32
+		err := forEach(context.Background(), 1000, workers, func(ctx context.Context, i int) error {
+			atomic.AddInt32(&calls, 1)
+			if i == 3 {
+				return errBoom
+			}
+			return nil
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("%d workers, expected boom error, got %v", workers, err)
+		}
+		if workers == 1 && calls != 4 {
+			t.Fatalf("%d workers, expected 4 calls, got %d", workers, calls)
+		}
+	}
+}
+
+func TestCallEachSemaphore(t *testing.T) {
+	sem := newSemaphore(2)
+	var running, peak int32
+	items := make([]int, 40)
+	err := callEach(context.Background(), items, 8, sem, func(int) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if peak > 2 {
+		t.Fatalf("expected at most 2 concurrent calls, got %d", peak)
+	}
+}
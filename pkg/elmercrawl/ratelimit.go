@@ -0,0 +1,190 @@
+package elmercrawl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	// throttleBackoff is the factor a rate is multiplied by after a
+	// throttling response.
+	throttleBackoff = 0.5
+	// recoveryFraction is the share of the configured rate added back after
+	// each successful request, so recovering from one backoff takes
+	// several dozen requests.
+	recoveryFraction = 0.02
+	// minRateFraction is the lowest share of the configured rate a backoff
+	// can reach.
+	minRateFraction = 0.05
+)
+
+// RateLimiter paces Glue requests per API operation. Each operation starts at
+// its configured requests per second, halves its rate when Glue throttles it
+// and creeps back up to the configured rate as requests succeed.
+type RateLimiter struct {
+	mu      sync.Mutex
+	def     float64
+	limits  map[string]float64
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	limit float64
+	rate  float64
+	next  time.Time
+}
+
+// NewRateLimiter returns a RateLimiter that allows limits[api] requests per
+// second for each Glue operation, such as "GetPartitions", and defaultLimit
+// for operations missing from limits. A limit of zero leaves an operation
+// unpaced.
+func NewRateLimiter(defaultLimit float64, limits map[string]float64) *RateLimiter {
+	l := &RateLimiter{
+		def:     defaultLimit,
+		limits:  map[string]float64{},
+		buckets: map[string]*rateBucket{},
+	}
+	for api, limit := range limits {
+		l.limits[api] = limit
+	}
+	return l
+}
+
+func (l *RateLimiter) bucket(api string) *rateBucket {
+	b, ok := l.buckets[api]
+	if ok {
+		return b
+	}
+	limit, ok := l.limits[api]
+	if !ok {
+		limit = l.def
+	}
+	if limit <= 0 {
+		return nil
+	}
+	b = &rateBucket{limit: limit, rate: limit}
+	l.buckets[api] = b
+	return b
+}
+
+// Wait blocks until a request to api may be sent or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context, api string) error {
+	l.mu.Lock()
+	b := l.bucket(api)
+	if b == nil {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	at := b.next
+	if at.Before(now) {
+		at = now
+	}
+	b.next = at.Add(time.Duration(float64(time.Second) / b.rate))
+	l.mu.Unlock()
+
+	wait := at.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Throttled lowers the rate for api after Glue rejected a request to it.
+func (l *RateLimiter) Throttled(api string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.bucket(api)
+	if b == nil {
+		return
+	}
+	b.rate *= throttleBackoff
+	if min := b.limit * minRateFraction; b.rate < min {
+		b.rate = min
+	}
+}
+
+// Succeeded raises the rate for api back towards its configured limit.
+func (l *RateLimiter) Succeeded(api string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.bucket(api)
+	if b == nil {
+		return
+	}
+	b.rate += b.limit * recoveryFraction
+	if b.rate > b.limit {
+		b.rate = b.limit
+	}
+}
+
+// Rate returns the current requests per second allowed for api, or zero when
+// api is unpaced.
+func (l *RateLimiter) Rate(api string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.bucket(api)
+	if b == nil {
+		return 0
+	}
+	return b.rate
+}
+
+// ThrottledError is returned when Glue rejected a request with a throttling
+// error code.
+type ThrottledError struct {
+	API string
+	Err error
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s throttled: %v", e.API, e.Err)
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return e.Err
+}
+
+// call sends one Glue request through the crawler's rate limiter and retry
+// policy, reporting each outcome back to the rate limiter.
+func (c *Crawler) call(ctx context.Context, api string, fn func() error) error {
+	return c.callWithRetry(ctx, api, c.Retry, fn)
+}
+
+// callWithRetry is call with another retry policy than the crawler's.
+func (c *Crawler) callWithRetry(ctx context.Context, api string, retry *RetryPolicy, fn func() error) error {
+	try := func() error {
+		if c.RateLimiter != nil {
+			err := c.RateLimiter.Wait(ctx, api)
+			if err != nil {
+				return err
+			}
+		}
+		err := fn()
+		if request.IsErrorThrottle(err) {
+			if c.RateLimiter != nil {
+				c.RateLimiter.Throttled(api)
+			}
+			return &ThrottledError{API: api, Err: err}
+		}
+		if err == nil && c.RateLimiter != nil {
+			c.RateLimiter.Succeeded(api)
+		}
+		return err
+	}
+	if retry == nil {
+		return try()
+	}
+	return retry.retry(ctx, api, try)
+}
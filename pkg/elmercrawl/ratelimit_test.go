@@ -0,0 +1,83 @@
+package elmercrawl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/glue/glueiface"
+)
+
+func TestRateLimiterBackoffAndRecovery(t *testing.T) {
+	l := NewRateLimiter(0, map[string]float64{"GetPartitions": 10})
+	if rate := l.Rate("GetTables"); rate != 0 {
+		t.Fatalf("expected GetTables to be unpaced, got %v", rate)
+	}
+	l.Throttled("GetPartitions")
+	if rate := l.Rate("GetPartitions"); rate != 5 {
+		t.Fatalf("expected rate 5 after throttling, got %v", rate)
+	}
+	for i := 0; i < 10; i++ {
+		l.Throttled("GetPartitions")
+	}
+	if rate := l.Rate("GetPartitions"); rate != 0.5 {
+		t.Fatalf("expected rate to bottom out at 0.5, got %v", rate)
+	}
+	l.Succeeded("GetPartitions")
+	if rate := l.Rate("GetPartitions"); rate != 0.7 {
+		t.Fatalf("expected rate 0.7 after one success, got %v", rate)
+	}
+	for i := 0; i < 100; i++ {
+		l.Succeeded("GetPartitions")
+	}
+	if rate := l.Rate("GetPartitions"); rate != 10 {
+		t.Fatalf("expected rate to recover to 10, got %v", rate)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	l := NewRateLimiter(100, nil)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := l.Wait(context.Background(), "GetTables"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected 6 requests at 100/s to take at least 50ms, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l = NewRateLimiter(0.001, nil)
+	l.Wait(ctx, "GetTables")
+	if err := l.Wait(ctx, "GetTables"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+type mockedThrottledGetDatabases struct {
+	glueiface.GlueAPI
+}
+
+func (m mockedThrottledGetDatabases) GetDatabasesWithContext(_ aws.Context, _ *glue.GetDatabasesInput, _ ...request.Option) (*glue.GetDatabasesOutput, error) {
+	return nil, awserr.New("ThrottlingException", "Rate exceeded", nil)
+}
+
+func TestCrawlDatabasesThrottled(t *testing.T) {
+	l := NewRateLimiter(0, map[string]float64{"GetDatabases": 4})
+	crawler := Crawler{Glue: mockedThrottledGetDatabases{}, RateLimiter: l}
+	err := crawler.CrawlDatabases(func(d *glue.Database) error { return nil })
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) || throttled.API != "GetDatabases" {
+		t.Fatalf("expected ThrottledError for GetDatabases, got %v", err)
+	}
+	if rate := l.Rate("GetDatabases"); rate != 2 {
+		t.Fatalf("expected GetDatabases rate to back off to 2, got %v", rate)
+	}
+}
@@ -0,0 +1,138 @@
+package elmercrawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// DefaultRetryableCodes are the Glue error codes retried when a RetryPolicy
+// does not list its own.
+var DefaultRetryableCodes = []string{
+	"ThrottlingException",
+	"InternalServiceException",
+	"OperationTimeoutException",
+	request.ErrCodeRequestError,
+}
+
+// RetryPolicy controls how often a single page request is retried before a
+// crawl gives up.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries per page, including the
+	// first one.
+	MaxAttempts int
+	// BaseDelay is the wait before the second try. It doubles for every
+	// following try, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter is the fraction, between 0 and 1, of each delay that is
+	// randomized.
+	Jitter float64
+	// RetryableCodes are the AWS error codes worth retrying. When empty,
+	// DefaultRetryableCodes is used.
+	RetryableCodes []string
+
+	// throttleAttempts, when above MaxAttempts, is the total number of tries
+	// allowed for throttled requests, whether or not RetryableCodes lists
+	// their code.
+	throttleAttempts int
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
+	codes := p.RetryableCodes
+	if len(codes) == 0 {
+		codes = DefaultRetryableCodes
+	}
+	for _, code := range codes {
+		if aerr.Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(p.Jitter * rand.Float64() * float64(d))
+	}
+	return d
+}
+
+// RetryAttempt records one failed try of a page request.
+type RetryAttempt struct {
+	Err error
+	// Delay is how long the crawler waited before the next try.
+	Delay time.Duration
+}
+
+// RetryError is returned when a page request failed on every try the
+// RetryPolicy allowed, or failed with a non-retryable error after retrying.
+type RetryError struct {
+	API      string
+	Attempts []RetryAttempt
+}
+
+func (e *RetryError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s failed after %d attempts", e.API, len(e.Attempts))
+	for i, a := range e.Attempts {
+		fmt.Fprintf(&b, "; attempt %d: %v", i+1, a.Err)
+		if a.Delay > 0 {
+			fmt.Fprintf(&b, " (retried after %v)", a.Delay)
+		}
+	}
+	return b.String()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// retry calls fn until it succeeds, returns a non-retryable error or the
+// policy runs out of attempts.
+func (p *RetryPolicy) retry(ctx context.Context, api string, fn func() error) error {
+	var attempts []RetryAttempt
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		maxAttempts, retryable := p.MaxAttempts, p.retryable(err)
+		var throttled *ThrottledError
+		if p.throttleAttempts > maxAttempts && errors.As(err, &throttled) {
+			maxAttempts, retryable = p.throttleAttempts, true
+		}
+		if attempt >= maxAttempts || !retryable {
+			if len(attempts) == 0 {
+				return err
+			}
+			return &RetryError{API: api, Attempts: append(attempts, RetryAttempt{Err: err})}
+		}
+		delay := p.delay(attempt)
+		attempts = append(attempts, RetryAttempt{Err: err, Delay: delay})
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return &RetryError{API: api, Attempts: append(attempts, RetryAttempt{Err: ctx.Err()})}
+		}
+	}
+}
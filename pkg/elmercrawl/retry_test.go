@@ -0,0 +1,114 @@
+package elmercrawl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type mockedFlakyGetTables struct {
+	mockedCatalog
+	Failures int
+	Code     string
+	calls    *int
+}
+
+func (m mockedFlakyGetTables) GetTablesWithContext(ctx aws.Context, in *glue.GetTablesInput, opts ...request.Option) (*glue.GetTablesOutput, error) {
+	if in.NextToken != nil {
+		*m.calls++
+		if *m.calls <= m.Failures {
+			return nil, awserr.New(m.Code, "mocked failure", nil)
+		}
+	}
+	return m.mockedCatalog.GetTablesWithContext(ctx, in, opts...)
+}
+
+func TestCrawlTablesRetry(t *testing.T) {
+	cases := []struct {
+		Failures    int
+		Code        string
+		MaxAttempts int
+		Tables      int
+		Attempts    int
+	}{
+		{Failures: 2, Code: "InternalServiceException", MaxAttempts: 3, Tables: 5},
+		{Failures: 5, Code: "InternalServiceException", MaxAttempts: 3, Attempts: 3},
+		{Failures: 1, Code: "EntityNotFoundException", MaxAttempts: 3},
+	}
+
+	for i, c := range cases {
+		calls := 0
+		crawler := Crawler{
+			Glue: mockedFlakyGetTables{
+				mockedCatalog: newMockedCatalog(1, 5, 0),
+				Failures:      c.Failures,
+				Code:          c.Code,
+				calls:         &calls,
+			},
+			Retry: &RetryPolicy{
+				MaxAttempts: c.MaxAttempts,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    2 * time.Millisecond,
+				Jitter:      0.5,
+			},
+		}
+		tables := 0
+		err := crawler.CrawlTables(func(t *glue.TableData) error {
+			tables++
+			return nil
+		})
+		if c.Tables > 0 {
+			if err != nil {
+				t.Fatalf("%d, unexpected error: %v", i, err)
+			}
+			if tables != c.Tables {
+				t.Fatalf("%d, expected %d tables, got %d", i, c.Tables, tables)
+			}
+			continue
+		}
+		if err == nil {
+			t.Fatalf("%d, expected error", i)
+		}
+		if crawler.tables != nil {
+			t.Fatalf("%d, expected tables not to be cached after a failed crawl", i)
+		}
+		var retryErr *RetryError
+		if c.Attempts == 0 {
+			if errors.As(err, &retryErr) {
+				t.Fatalf("%d, expected non-retryable error to be returned as is, got %v", i, err)
+			}
+			continue
+		}
+		if !errors.As(err, &retryErr) {
+			t.Fatalf("%d, expected RetryError, got %v", i, err)
+		}
+		if retryErr.API != "GetTables" || len(retryErr.Attempts) != c.Attempts {
+			t.Fatalf("%d, expected %d GetTables attempts, got %s with %d", i, c.Attempts, retryErr.API, len(retryErr.Attempts))
+		}
+		if !strings.Contains(err.Error(), "attempt 3: InternalServiceException") {
+			t.Fatalf("%d, expected attempt history in error, got %v", i, err)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i := range expected {
+		if d := p.delay(i + 1); d != expected[i]*time.Millisecond {
+			t.Fatalf("attempt %d, expected %v delay, got %v", i+1, expected[i]*time.Millisecond, d)
+		}
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.delay(2); d < 100*time.Millisecond || d > 200*time.Millisecond {
+			t.Fatalf("expected jittered delay between 100ms and 200ms, got %v", d)
+		}
+	}
+}
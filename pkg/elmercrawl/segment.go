@@ -0,0 +1,78 @@
+package elmercrawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// maxPartitionSegments is the largest TotalSegments GetPartitions accepts.
+const maxPartitionSegments = 10
+
+var errSegmentThresholdExceeded = errors.New("segment threshold exceeded")
+
+// walkPartitionPagesSegmented lists a table in PartitionSegments parallel
+// segments. With a SegmentThreshold the table is first listed normally,
+// holding back at most SegmentThreshold partitions, and only re-listed in
+// segments once it turns out to be larger than that.
+func (c *Crawler) walkPartitionPagesSegmented(ctx context.Context, table *glue.TableData, expression *string, pagef func([]*glue.Partition) error) error {
+	if c.PartitionSegments > maxPartitionSegments {
+		return fmt.Errorf("walkPartitionPagesSegmented got %d segments, at most %d are supported", c.PartitionSegments, maxPartitionSegments)
+	}
+	if c.SegmentThreshold > 0 {
+		var held []*glue.Partition
+		err := c.walkPartitionSegment(ctx, table, expression, nil, func(page []*glue.Partition) error {
+			held = append(held, page...)
+			if len(held) > c.SegmentThreshold {
+				return errSegmentThresholdExceeded
+			}
+			return nil
+		})
+		if err == nil {
+			return pagef(held)
+		}
+		if !errors.Is(err, errSegmentThresholdExceeded) {
+			return err
+		}
+	}
+
+	// Streamed pages are handed on as soon as any segment returns them.
+	// Cached pages are held per segment and handed on in segment order so
+	// that the cache does not depend on timing.
+	var (
+		mu      sync.Mutex
+		ordered = make([][]*glue.Partition, c.PartitionSegments)
+	)
+	err := forEach(ctx, c.PartitionSegments, c.PartitionSegments, func(ctx context.Context, i int) error {
+		segment := &glue.Segment{
+			SegmentNumber: aws.Int64(int64(i)),
+			TotalSegments: aws.Int64(int64(c.PartitionSegments)),
+		}
+		return c.walkPartitionSegment(ctx, table, expression, segment, func(page []*glue.Partition) error {
+			if !c.Stream {
+				ordered[i] = append(ordered[i], page...)
+				return nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			return pagef(page)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	for i := range ordered {
+		if len(ordered[i]) == 0 {
+			continue
+		}
+		err = pagef(ordered[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
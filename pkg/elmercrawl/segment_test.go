@@ -0,0 +1,104 @@
+package elmercrawl
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type mockedSegmentGetPartitions struct {
+	mockedCatalog
+	mu       *sync.Mutex
+	segments map[string]int
+}
+
+func (m mockedSegmentGetPartitions) GetPartitionsWithContext(ctx aws.Context, in *glue.GetPartitionsInput, opts ...request.Option) (*glue.GetPartitionsOutput, error) {
+	if in.Segment != nil {
+		m.mu.Lock()
+		m.segments[*in.TableName]++
+		m.mu.Unlock()
+	}
+	return m.mockedCatalog.GetPartitionsWithContext(ctx, in, opts...)
+}
+
+func TestCrawlPartitionsSegmented(t *testing.T) {
+	cases := []struct {
+		Segments  int
+		Threshold int
+		Segmented map[string]bool
+	}{
+		{Segments: 3, Segmented: map[string]bool{"small": true, "large": true}},
+		{Segments: 3, Threshold: 4, Segmented: map[string]bool{"large": true}},
+		{Segments: 3, Threshold: 20, Segmented: map[string]bool{}},
+	}
+
+	for i, c := range cases {
+		for _, stream := range []bool{false, true} {
+			catalog := newMockedCatalog(1, 0, 0)
+			for _, tbl := range []struct {
+				name string
+				n    int
+			}{{"small", 3}, {"large", 10}} {
+				name, n := tbl.name, tbl.n
+				catalog.Tables["testdb0"] = append(catalog.Tables["testdb0"], &glue.TableData{
+					DatabaseName: aws.String("testdb0"),
+					Name:         aws.String(name),
+				})
+				for k := 0; k < n; k++ {
+					catalog.Partitions["testdb0."+name] = append(catalog.Partitions["testdb0."+name], &glue.Partition{
+						DatabaseName: aws.String("testdb0"),
+						TableName:    aws.String(name),
+						Values:       []*string{aws.String(fmt.Sprintf("%02d", k))},
+					})
+				}
+			}
+			mock := mockedSegmentGetPartitions{mockedCatalog: catalog, mu: &sync.Mutex{}, segments: map[string]int{}}
+			crawler := Crawler{
+				Glue:              mock,
+				Stream:            stream,
+				PartitionSegments: c.Segments,
+				SegmentThreshold:  c.Threshold,
+			}
+			seen := map[string]int{}
+			err := crawler.CrawlPartitions(func(p *glue.Partition) error {
+				seen[*p.TableName+"/"+*p.Values[0]]++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("%d, stream %t, unexpected error: %v", i, stream, err)
+			}
+			if len(seen) != 13 {
+				t.Fatalf("%d, stream %t, expected 13 partitions, got %d", i, stream, len(seen))
+			}
+			for k, n := range seen {
+				if n != 1 {
+					t.Fatalf("%d, stream %t, expected %s once, got %d", i, stream, k, n)
+				}
+			}
+			for _, name := range []string{"small", "large"} {
+				if (mock.segments[name] > 0) != c.Segmented[name] {
+					t.Fatalf("%d, stream %t, expected %s segmented %t, got %d segment calls", i, stream, name, c.Segmented[name], mock.segments[name])
+				}
+			}
+			if !stream && c.Segmented["large"] {
+				// Segment 0 of 3 holds every third partition.
+				expected := []string{"00", "03", "06", "09", "01"}
+				for j := range expected {
+					p := crawler.partitions[3+j]
+					if *p.TableName != "large" || *p.Values[0] != expected[j] {
+						t.Fatalf("%d, expected cached partition large/%s at %d, got %s/%s", i, expected[j], 3+j, *p.TableName, *p.Values[0])
+					}
+				}
+			}
+		}
+	}
+
+	crawler := Crawler{Glue: newMockedCatalog(1, 1, 1), PartitionSegments: 11}
+	if err := crawler.CrawlPartitions(func(p *glue.Partition) error { return nil }); err == nil {
+		t.Fatalf("expected error for more than 10 segments")
+	}
+}
@@ -0,0 +1,166 @@
+package elmercrawl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// SnapshotVersion is the version of the snapshot file format written by this
+// package. Snapshots with a newer version are rejected when read.
+const SnapshotVersion = 1
+
+// Snapshot is the content of a catalog at one point in time.
+type Snapshot struct {
+	Version int
+	// CatalogId is the catalog the snapshot was taken of, empty for the
+	// caller's own catalog.
+	CatalogId string `json:",omitempty"`
+	Region    string `json:",omitempty"`
+	CreatedAt time.Time
+
+	Databases     []*glue.Database
+	Tables        []*glue.TableData
+	Partitions    []*glue.Partition
+	TableVersions []*glue.TableVersion `json:",omitempty"`
+}
+
+// snapshotHeader is the first line of an NDJSON snapshot.
+type snapshotHeader struct {
+	Version   int
+	CatalogId string `json:",omitempty"`
+	Region    string `json:",omitempty"`
+	CreatedAt time.Time
+}
+
+// snapshotRecord is one object line of an NDJSON snapshot. Exactly one field
+// is set.
+type snapshotRecord struct {
+	Database     *glue.Database     `json:",omitempty"`
+	Table        *glue.TableData    `json:",omitempty"`
+	Partition    *glue.Partition    `json:",omitempty"`
+	TableVersion *glue.TableVersion `json:",omitempty"`
+}
+
+// Snapshot returns the databases, tables and partitions of the catalog, and
+// the versions of every table when tableVersions is set. It fills and reuses
+// the crawler's caches, also in Stream mode.
+func (c *Crawler) Snapshot(ctx context.Context, tableVersions bool) (*Snapshot, error) {
+	createdAt := time.Now().UTC()
+	err := c.loadPartitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Snapshot failed to get partitions: %w", err)
+	}
+	s := &Snapshot{
+		Version:    SnapshotVersion,
+		CatalogId:  c.CatalogId,
+		Region:     c.Region,
+		CreatedAt:  createdAt,
+		Databases:  c.databases,
+		Tables:     c.tables,
+		Partitions: c.partitions,
+	}
+	if tableVersions {
+		err = c.loadTableVersions(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Snapshot failed to get table versions: %w", err)
+		}
+		s.TableVersions = c.tableVersions
+	}
+	return s, nil
+}
+
+// WriteJSON writes the snapshot as a single JSON document.
+func (s *Snapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(s)
+	if err != nil {
+		return fmt.Errorf("WriteJSON failed to encode snapshot: %w", err)
+	}
+	return nil
+}
+
+// WriteNDJSON writes the snapshot as newline-delimited JSON: a header line
+// followed by one line per database, table, partition and table version.
+func (s *Snapshot) WriteNDJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	err := enc.Encode(snapshotHeader{Version: s.Version, CatalogId: s.CatalogId, Region: s.Region, CreatedAt: s.CreatedAt})
+	if err != nil {
+		return fmt.Errorf("WriteNDJSON failed to encode header: %w", err)
+	}
+	encode := func(record snapshotRecord) error {
+		err := enc.Encode(record)
+		if err != nil {
+			return fmt.Errorf("WriteNDJSON failed to encode record: %w", err)
+		}
+		return nil
+	}
+	for _, db := range s.Databases {
+		if err := encode(snapshotRecord{Database: db}); err != nil {
+			return err
+		}
+	}
+	for _, table := range s.Tables {
+		if err := encode(snapshotRecord{Table: table}); err != nil {
+			return err
+		}
+	}
+	for _, partition := range s.Partitions {
+		if err := encode(snapshotRecord{Partition: partition}); err != nil {
+			return err
+		}
+	}
+	for _, version := range s.TableVersions {
+		if err := encode(snapshotRecord{TableVersion: version}); err != nil {
+			return err
+		}
+	}
+	err = bw.Flush()
+	if err != nil {
+		return fmt.Errorf("WriteNDJSON failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// ReadSnapshot reads a snapshot written by WriteJSON or WriteNDJSON.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	s := &Snapshot{}
+	err := dec.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("ReadSnapshot failed to decode snapshot: %w", err)
+	}
+	if s.Version < 1 || s.Version > SnapshotVersion {
+		return nil, fmt.Errorf("ReadSnapshot got snapshot version %d, only versions 1 to %d are supported", s.Version, SnapshotVersion)
+	}
+	for {
+		var record snapshotRecord
+		err = dec.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			return s, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ReadSnapshot failed to decode record: %w", err)
+		}
+		switch {
+		case record.Database != nil:
+			s.Databases = append(s.Databases, record.Database)
+		case record.Table != nil:
+			s.Tables = append(s.Tables, record.Table)
+		case record.Partition != nil:
+			s.Partitions = append(s.Partitions, record.Partition)
+		case record.TableVersion != nil:
+			s.TableVersions = append(s.TableVersions, record.TableVersion)
+		default:
+			return nil, errors.New("ReadSnapshot got a record without an object")
+		}
+	}
+}
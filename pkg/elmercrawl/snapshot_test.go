@@ -0,0 +1,58 @@
+package elmercrawl
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	crawler := Crawler{
+		Glue:      mockedGetTableVersions{mockedCatalog: newMockedCatalog(2, 2, 3), Versions: 2},
+		CatalogId: "123456789012",
+		Region:    "eu-west-1",
+		Stream:    true,
+	}
+	s, err := crawler.Snapshot(context.Background(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Databases) != 2 || len(s.Tables) != 4 || len(s.Partitions) != 12 || len(s.TableVersions) != 8 {
+		t.Fatalf("unexpected snapshot size: %d databases, %d tables, %d partitions, %d table versions", len(s.Databases), len(s.Tables), len(s.Partitions), len(s.TableVersions))
+	}
+	for _, format := range []string{"json", "ndjson"} {
+		var buf bytes.Buffer
+		if format == "json" {
+			err = s.WriteJSON(&buf)
+		} else {
+			err = s.WriteNDJSON(&buf)
+		}
+		if err != nil {
+			t.Fatalf("%s, unexpected error: %v", format, err)
+		}
+		if format == "ndjson" && strings.Count(buf.String(), "\n") != 1+2+4+12+8 {
+			t.Fatalf("%s, expected one line per object and a header, got %d lines", format, strings.Count(buf.String(), "\n"))
+		}
+		read, err := ReadSnapshot(&buf)
+		if err != nil {
+			t.Fatalf("%s, unexpected error: %v", format, err)
+		}
+		if read.CatalogId != "123456789012" || read.Region != "eu-west-1" || !read.CreatedAt.Equal(s.CreatedAt) {
+			t.Fatalf("%s, unexpected header %q %q %v", format, read.CatalogId, read.Region, read.CreatedAt)
+		}
+		if len(read.Partitions) != 12 || *read.Partitions[11].TableName != "testtable1" || *read.Partitions[11].Values[0] != "20220902" {
+			t.Fatalf("%s, unexpected partitions %v", format, read.Partitions)
+		}
+		if len(read.TableVersions) != 8 || *read.TableVersions[7].VersionId != "1" {
+			t.Fatalf("%s, unexpected table versions %v", format, read.TableVersions)
+		}
+	}
+}
+
+func TestReadSnapshotVersion(t *testing.T) {
+	_, err := ReadSnapshot(strings.NewReader(`{"Version": 2}`))
+	if err == nil {
+		t.Fatalf("expected a newer snapshot version to be rejected")
+	}
+}
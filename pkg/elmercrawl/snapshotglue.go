@@ -0,0 +1,199 @@
+package elmercrawl
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/glue/glueiface"
+)
+
+// snapshotPageSize is the number of objects SnapshotGlue returns per page
+// when a request does not set MaxResults.
+const snapshotPageSize = 100
+
+// SnapshotGlue serves GetDatabases, GetTables, GetPartitions and
+// GetTableVersions from a Snapshot instead of AWS, paging through the
+// results like Glue does. Partition filter expressions are not supported.
+// Any other Glue operation panics.
+type SnapshotGlue struct {
+	glueiface.GlueAPI
+	snapshot   *Snapshot
+	tables     map[string][]*glue.TableData
+	partitions map[string][]*glue.Partition
+	versions   map[string][]*glue.TableVersion
+}
+
+func NewSnapshotGlue(s *Snapshot) *SnapshotGlue {
+	g := &SnapshotGlue{
+		snapshot:   s,
+		tables:     map[string][]*glue.TableData{},
+		partitions: map[string][]*glue.Partition{},
+		versions:   map[string][]*glue.TableVersion{},
+	}
+	for _, table := range s.Tables {
+		db := aws.StringValue(table.DatabaseName)
+		g.tables[db] = append(g.tables[db], table)
+	}
+	for _, partition := range s.Partitions {
+		key := aws.StringValue(partition.DatabaseName) + "." + aws.StringValue(partition.TableName)
+		g.partitions[key] = append(g.partitions[key], partition)
+	}
+	for _, version := range s.TableVersions {
+		if version.Table == nil {
+			continue
+		}
+		key := aws.StringValue(version.Table.DatabaseName) + "." + aws.StringValue(version.Table.Name)
+		g.versions[key] = append(g.versions[key], version)
+	}
+	return g
+}
+
+// checkCatalog fails requests for a catalog other than the snapshot's.
+func (g *SnapshotGlue) checkCatalog(catalogId *string) error {
+	if catalogId == nil || g.snapshot.CatalogId == "" || *catalogId == g.snapshot.CatalogId {
+		return nil
+	}
+	return awserr.New(glue.ErrCodeEntityNotFoundException, fmt.Sprintf("catalog %s is not in the snapshot", *catalogId), nil)
+}
+
+func (g *SnapshotGlue) hasDatabase(name string) bool {
+	for _, db := range g.snapshot.Databases {
+		if aws.StringValue(db.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *SnapshotGlue) hasTable(database, table string) bool {
+	for _, t := range g.tables[database] {
+		if aws.StringValue(t.Name) == table {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotPage returns the bounds of the page of n objects starting at token,
+// and the token of the following page.
+func snapshotPage(token *string, maxResults *int64, n int) (int, int, *string, error) {
+	start := 0
+	if token != nil {
+		var err error
+		start, err = strconv.Atoi(*token)
+		if err != nil || start < 0 || start > n {
+			return 0, 0, nil, awserr.New(glue.ErrCodeInvalidInputException, fmt.Sprintf("invalid next token %q", *token), nil)
+		}
+	}
+	size := snapshotPageSize
+	if maxResults != nil && *maxResults > 0 {
+		size = int(*maxResults)
+	}
+	if start+size < n {
+		return start, start + size, aws.String(strconv.Itoa(start + size)), nil
+	}
+	return start, n, nil, nil
+}
+
+// segmentBounds returns the part of n objects that belongs to segment.
+func segmentBounds(segment *glue.Segment, n int) (int, int, error) {
+	if segment == nil {
+		return 0, n, nil
+	}
+	number, total := int(aws.Int64Value(segment.SegmentNumber)), int(aws.Int64Value(segment.TotalSegments))
+	if total < 1 || number < 0 || number >= total {
+		return 0, 0, awserr.New(glue.ErrCodeInvalidInputException, fmt.Sprintf("invalid segment %d of %d", number, total), nil)
+	}
+	return n * number / total, n * (number + 1) / total, nil
+}
+
+func (g *SnapshotGlue) GetDatabases(in *glue.GetDatabasesInput) (*glue.GetDatabasesOutput, error) {
+	return g.GetDatabasesWithContext(aws.BackgroundContext(), in)
+}
+
+func (g *SnapshotGlue) GetDatabasesWithContext(_ aws.Context, in *glue.GetDatabasesInput, _ ...request.Option) (*glue.GetDatabasesOutput, error) {
+	if err := g.checkCatalog(in.CatalogId); err != nil {
+		return nil, err
+	}
+	databases := g.snapshot.Databases
+	start, end, next, err := snapshotPage(in.NextToken, in.MaxResults, len(databases))
+	if err != nil {
+		return nil, err
+	}
+	return &glue.GetDatabasesOutput{DatabaseList: databases[start:end], NextToken: next}, nil
+}
+
+func (g *SnapshotGlue) GetTables(in *glue.GetTablesInput) (*glue.GetTablesOutput, error) {
+	return g.GetTablesWithContext(aws.BackgroundContext(), in)
+}
+
+func (g *SnapshotGlue) GetTablesWithContext(_ aws.Context, in *glue.GetTablesInput, _ ...request.Option) (*glue.GetTablesOutput, error) {
+	if err := g.checkCatalog(in.CatalogId); err != nil {
+		return nil, err
+	}
+	if in.Expression != nil {
+		return nil, awserr.New(glue.ErrCodeInvalidInputException, "table expressions are not supported by snapshots", nil)
+	}
+	db := aws.StringValue(in.DatabaseName)
+	if !g.hasDatabase(db) {
+		return nil, awserr.New(glue.ErrCodeEntityNotFoundException, fmt.Sprintf("database %s not found", db), nil)
+	}
+	tables := g.tables[db]
+	start, end, next, err := snapshotPage(in.NextToken, in.MaxResults, len(tables))
+	if err != nil {
+		return nil, err
+	}
+	return &glue.GetTablesOutput{TableList: tables[start:end], NextToken: next}, nil
+}
+
+func (g *SnapshotGlue) GetPartitions(in *glue.GetPartitionsInput) (*glue.GetPartitionsOutput, error) {
+	return g.GetPartitionsWithContext(aws.BackgroundContext(), in)
+}
+
+func (g *SnapshotGlue) GetPartitionsWithContext(_ aws.Context, in *glue.GetPartitionsInput, _ ...request.Option) (*glue.GetPartitionsOutput, error) {
+	if err := g.checkCatalog(in.CatalogId); err != nil {
+		return nil, err
+	}
+	if in.Expression != nil {
+		return nil, awserr.New(glue.ErrCodeInvalidInputException, "partition expressions are not supported by snapshots", nil)
+	}
+	db, table := aws.StringValue(in.DatabaseName), aws.StringValue(in.TableName)
+	if !g.hasTable(db, table) {
+		return nil, awserr.New(glue.ErrCodeEntityNotFoundException, fmt.Sprintf("table %s.%s not found", db, table), nil)
+	}
+	partitions := g.partitions[db+"."+table]
+	from, to, err := segmentBounds(in.Segment, len(partitions))
+	if err != nil {
+		return nil, err
+	}
+	partitions = partitions[from:to]
+	start, end, next, err := snapshotPage(in.NextToken, in.MaxResults, len(partitions))
+	if err != nil {
+		return nil, err
+	}
+	return &glue.GetPartitionsOutput{Partitions: partitions[start:end], NextToken: next}, nil
+}
+
+func (g *SnapshotGlue) GetTableVersions(in *glue.GetTableVersionsInput) (*glue.GetTableVersionsOutput, error) {
+	return g.GetTableVersionsWithContext(aws.BackgroundContext(), in)
+}
+
+func (g *SnapshotGlue) GetTableVersionsWithContext(_ aws.Context, in *glue.GetTableVersionsInput, _ ...request.Option) (*glue.GetTableVersionsOutput, error) {
+	if err := g.checkCatalog(in.CatalogId); err != nil {
+		return nil, err
+	}
+	db, table := aws.StringValue(in.DatabaseName), aws.StringValue(in.TableName)
+	if !g.hasTable(db, table) {
+		return nil, awserr.New(glue.ErrCodeEntityNotFoundException, fmt.Sprintf("table %s.%s not found", db, table), nil)
+	}
+	versions := g.versions[db+"."+table]
+	start, end, next, err := snapshotPage(in.NextToken, in.MaxResults, len(versions))
+	if err != nil {
+		return nil, err
+	}
+	return &glue.GetTableVersionsOutput{TableVersions: versions[start:end], NextToken: next}, nil
+}
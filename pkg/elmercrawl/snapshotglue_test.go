@@ -0,0 +1,87 @@
+package elmercrawl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+func newTestSnapshot(t *testing.T, databases, tablesPerDatabase, partitionsPerTable int) *Snapshot {
+	crawler := Crawler{Glue: newMockedCatalog(databases, tablesPerDatabase, partitionsPerTable), CatalogId: "123456789012"}
+	s, err := crawler.Snapshot(context.Background(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s
+}
+
+func TestSnapshotGluePagination(t *testing.T) {
+	g := NewSnapshotGlue(newTestSnapshot(t, 1, 1, 5))
+	var got []string
+	input := &glue.GetPartitionsInput{
+		CatalogId:    aws.String("123456789012"),
+		DatabaseName: aws.String("testdb0"),
+		TableName:    aws.String("testtable0"),
+		MaxResults:   aws.Int64(2),
+	}
+	pages := 0
+	for {
+		out, err := g.GetPartitions(input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pages++
+		for _, p := range out.Partitions {
+			got = append(got, *p.Values[0])
+		}
+		if out.NextToken == nil {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+	if pages != 3 || len(got) != 5 || got[4] != "20220904" {
+		t.Fatalf("expected 5 partitions on 3 pages, got %v on %d", got, pages)
+	}
+}
+
+func TestSnapshotGlueCrawl(t *testing.T) {
+	for _, segments := range []int{0, 3} {
+		crawler := Crawler{
+			Glue:              NewSnapshotGlue(newTestSnapshot(t, 3, 2, 4)),
+			CatalogId:         "123456789012",
+			PartitionSegments: segments,
+		}
+		var got []string
+		err := crawler.CrawlPartitions(func(p *glue.Partition) error {
+			got = append(got, *p.DatabaseName+"."+*p.TableName+"/"+*p.Values[0])
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%d segments, unexpected error: %v", segments, err)
+		}
+		if len(got) != 24 || got[0] != "testdb0.testtable0/20220900" || got[23] != "testdb2.testtable1/20220903" {
+			t.Fatalf("%d segments, unexpected partitions %v", segments, got)
+		}
+	}
+}
+
+func TestSnapshotGlueErrors(t *testing.T) {
+	g := NewSnapshotGlue(newTestSnapshot(t, 1, 1, 1))
+	_, err := g.GetTables(&glue.GetTablesInput{CatalogId: aws.String("210987654321"), DatabaseName: aws.String("testdb0")})
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) || aerr.Code() != glue.ErrCodeEntityNotFoundException {
+		t.Fatalf("expected EntityNotFoundException for another catalog, got %v", err)
+	}
+	_, err = g.GetPartitions(&glue.GetPartitionsInput{DatabaseName: aws.String("testdb0"), TableName: aws.String("missing")})
+	if !errors.As(err, &aerr) || aerr.Code() != glue.ErrCodeEntityNotFoundException {
+		t.Fatalf("expected EntityNotFoundException for a missing table, got %v", err)
+	}
+	_, err = g.GetDatabases(&glue.GetDatabasesInput{NextToken: aws.String("bogus")})
+	if !errors.As(err, &aerr) || aerr.Code() != glue.ErrCodeInvalidInputException {
+		t.Fatalf("expected InvalidInputException for a bad token, got %v", err)
+	}
+}
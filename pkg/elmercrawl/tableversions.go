@@ -0,0 +1,92 @@
+package elmercrawl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type glueTableVersionFunc func(*glue.TableVersion) error
+
+func (c *Crawler) CrawlTableVersions(gtvf glueTableVersionFunc) error {
+	return c.CrawlTableVersionsWithContext(context.Background(), gtvf)
+}
+
+func (c *Crawler) CrawlTableVersionsWithContext(ctx context.Context, gtvf glueTableVersionFunc) error {
+	var failures *errorCollector
+	if c.KeepGoing {
+		failures = c.newErrorCollector()
+		gtvf = failures.tableVersionFunc(gtvf)
+	}
+	if c.Stream {
+		err := c.streamTableVersions(ctx, gtvf)
+		if err != nil {
+			return fmt.Errorf("CrawlTableVersions failed to stream table versions: %w", err)
+		}
+		return failures.err()
+	}
+	err := c.loadTableVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("CrawlTableVersions failed to get table versions: %w", err)
+	}
+	err = callEach(ctx, c.tableVersions, c.Parallel, nil, gtvf)
+	if err != nil {
+		return fmt.Errorf("CrawlTableVersions failed to run function: %w", err)
+	}
+	return failures.err()
+}
+
+func (c *Crawler) getTableVersions(ctx context.Context) error {
+	err := c.loadTables(ctx)
+	if err != nil {
+		return fmt.Errorf("getTableVersions failed to get tables: %w", err)
+	}
+	versions, err := collectChildren(ctx, c.tables, c.Workers, c.walkTableVersionPages)
+	if err != nil {
+		return fmt.Errorf("getTableVersions failed to get table versions: %w", err)
+	}
+	c.tableVersions = versions
+	c.tableVersionsAt = time.Now()
+	return nil
+}
+
+func (c *Crawler) streamTableVersions(ctx context.Context, gtvf glueTableVersionFunc) error {
+	sem := newSemaphore(c.Parallel)
+	return c.streamEachTable(ctx, func(ctx context.Context, table *glue.TableData) error {
+		return c.walkTableVersionPages(ctx, table, func(page []*glue.TableVersion) error {
+			err := callEach(ctx, page, c.Parallel, sem, gtvf)
+			if err != nil {
+				return fmt.Errorf("streamTableVersions failed to run function: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+func (c *Crawler) walkTableVersionPages(ctx context.Context, table *glue.TableData, pagef func([]*glue.TableVersion) error) error {
+	return newIterator(ctx, c.tableVersionPages(table).nextPage).eachPage(pagef)
+}
+
+// tableVersionPages pages through the versions of table.
+func (c *Crawler) tableVersionPages(table *glue.TableData) *pager[*glue.TableVersion] {
+	return &pager[*glue.TableVersion]{fetch: func(ctx context.Context, token *string) ([]*glue.TableVersion, *string, error) {
+		input := &glue.GetTableVersionsInput{
+			CatalogId:    c.glueCatalogId(),
+			DatabaseName: table.DatabaseName,
+			TableName:    table.Name,
+			NextToken:    token,
+		}
+		var getVerOut *glue.GetTableVersionsOutput
+		err := c.call(ctx, "GetTableVersions", func() (err error) {
+			getVerOut, err = c.Glue.GetTableVersionsWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("tableVersionPages failed to get table versions for table %s.%s: %w", aws.StringValue(table.DatabaseName), aws.StringValue(table.Name), err)
+		}
+		return getVerOut.TableVersions, getVerOut.NextToken, nil
+	}}
+}
@@ -0,0 +1,56 @@
+package elmercrawl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type mockedGetTableVersions struct {
+	mockedCatalog
+	Versions int
+}
+
+func (m mockedGetTableVersions) GetTableVersionsWithContext(_ aws.Context, in *glue.GetTableVersionsInput, _ ...request.Option) (*glue.GetTableVersionsOutput, error) {
+	var versions []*glue.TableVersion
+	for i := 0; i < m.Versions; i++ {
+		versions = append(versions, &glue.TableVersion{
+			Table: &glue.TableData{
+				DatabaseName: in.DatabaseName,
+				Name:         in.TableName,
+			},
+			VersionId: aws.String(fmt.Sprintf("%d", i)),
+		})
+	}
+	start, end, next := m.page(in.NextToken, len(versions))
+	return &glue.GetTableVersionsOutput{TableVersions: versions[start:end], NextToken: next}, nil
+}
+
+func TestCrawlTableVersions(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		crawler := Crawler{
+			Glue:   mockedGetTableVersions{mockedCatalog: newMockedCatalog(2, 2, 0), Versions: 3},
+			Stream: stream,
+		}
+		var got []string
+		err := crawler.CrawlTableVersions(func(v *glue.TableVersion) error {
+			got = append(got, *v.Table.DatabaseName+"."+*v.Table.Name+"@"+*v.VersionId)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("stream %t, unexpected error: %v", stream, err)
+		}
+		if len(got) != 12 {
+			t.Fatalf("stream %t, expected 12 table versions, got %d", stream, len(got))
+		}
+		if got[0] != "testdb0.testtable0@0" || got[11] != "testdb1.testtable1@2" {
+			t.Fatalf("stream %t, unexpected table version order: first %s, last %s", stream, got[0], got[11])
+		}
+		if stream == (crawler.tableVersions != nil) {
+			t.Fatalf("stream %t, unexpected table version cache %v", stream, crawler.tableVersions)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package elmercrawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// SkipChildren is returned by a Visitor method to skip the objects below the
+// one being visited. Returned from VisitPartition it skips the remaining
+// partitions of the table.
+var SkipChildren = errors.New("skip children")
+
+// errSkipPartitions stops listing the partitions of a table after
+// VisitPartition returned SkipChildren.
+var errSkipPartitions = errors.New("skip partitions")
+
+// SkipAll is returned by a Visitor method to stop the walk without an error.
+var SkipAll = errors.New("skip all")
+
+// Visitor is called for every database, table and partition of a catalog,
+// together with the objects above it.
+type Visitor interface {
+	VisitDatabase(db *glue.Database) error
+	VisitTable(db *glue.Database, table *glue.TableData) error
+	VisitPartition(db *glue.Database, table *glue.TableData, partition *glue.Partition) error
+}
+
+// Walk visits the catalog depth first: each database, then each of its
+// tables, each followed by its partitions. Objects are visited one at a time
+// in catalog order and are never cached. Any error other than SkipChildren
+// or SkipAll stops the walk, unless KeepGoing is set, in which case the
+// failed object's children are skipped and the failures are returned
+// together as a *MultiError.
+func (c *Crawler) Walk(v Visitor) error {
+	return c.WalkWithContext(context.Background(), v)
+}
+
+func (c *Crawler) WalkWithContext(ctx context.Context, v Visitor) error {
+	if c.PartitionExpression != "" {
+		err := checkPartitionExpression(c.PartitionExpression, nil)
+		if err != nil {
+			return fmt.Errorf("Walk failed to parse expression: %w", err)
+		}
+	}
+	w := &walker{crawler: c, visitor: v}
+	if c.KeepGoing {
+		w.failures = c.newErrorCollector()
+	}
+	err := c.walkDatabasePages(ctx, func(page []*glue.Database) error {
+		for _, db := range page {
+			err := w.database(ctx, db)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, SkipAll) {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("Walk failed to visit catalog: %w", err)
+	}
+	return w.failures.err()
+}
+
+type walker struct {
+	crawler  *Crawler
+	visitor  Visitor
+	failures *errorCollector
+}
+
+// visited reports whether the children of an object should be walked after
+// visiting it returned err, and the error that stops the walk, if any.
+func (w *walker) visited(err error, object *ObjectError) (bool, error) {
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, SkipChildren):
+		return false, nil
+	case errors.Is(err, SkipAll) || w.failures == nil:
+		return false, err
+	}
+	object.Err = err
+	w.failures.add(object)
+	return false, nil
+}
+
+func (w *walker) database(ctx context.Context, db *glue.Database) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	descend, err := w.visited(w.visitor.VisitDatabase(db), &ObjectError{Database: aws.StringValue(db.Name)})
+	if !descend {
+		return err
+	}
+	return w.crawler.walkTablePages(ctx, db, func(page []*glue.TableData) error {
+		for _, table := range page {
+			err := w.table(ctx, db, table)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (w *walker) table(ctx context.Context, db *glue.Database, table *glue.TableData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	descend, err := w.visited(w.visitor.VisitTable(db, table), &ObjectError{
+		Database: aws.StringValue(table.DatabaseName),
+		Table:    aws.StringValue(table.Name),
+	})
+	if !descend {
+		return err
+	}
+	err = w.crawler.walkPartitionPages(ctx, table, func(page []*glue.Partition) error {
+		for _, partition := range page {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			err := w.visitor.VisitPartition(db, table, partition)
+			if errors.Is(err, SkipChildren) {
+				return errSkipPartitions
+			}
+			_, err = w.visited(err, &ObjectError{
+				Database:  aws.StringValue(partition.DatabaseName),
+				Table:     aws.StringValue(partition.TableName),
+				Partition: aws.StringValueSlice(partition.Values),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errSkipPartitions) {
+		return nil
+	}
+	return err
+}
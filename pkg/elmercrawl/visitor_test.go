@@ -0,0 +1,154 @@
+package elmercrawl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+type recordingVisitor struct {
+	visited   []string
+	database  func(*glue.Database) error
+	table     func(*glue.TableData) error
+	partition func(*glue.Partition) error
+}
+
+func (v *recordingVisitor) VisitDatabase(db *glue.Database) error {
+	v.visited = append(v.visited, *db.Name)
+	if v.database == nil {
+		return nil
+	}
+	return v.database(db)
+}
+
+func (v *recordingVisitor) VisitTable(db *glue.Database, table *glue.TableData) error {
+	v.visited = append(v.visited, *db.Name+"."+*table.Name)
+	if v.table == nil {
+		return nil
+	}
+	return v.table(table)
+}
+
+func (v *recordingVisitor) VisitPartition(db *glue.Database, table *glue.TableData, partition *glue.Partition) error {
+	v.visited = append(v.visited, *db.Name+"."+*table.Name+"/"+*partition.Values[0])
+	if v.partition == nil {
+		return nil
+	}
+	return v.partition(partition)
+}
+
+func TestWalk(t *testing.T) {
+	crawler := Crawler{Glue: newMockedCatalog(2, 2, 3)}
+	v := &recordingVisitor{}
+	err := crawler.Walk(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(v.visited) != 2+4+12 {
+		t.Fatalf("expected 18 visits, got %d", len(v.visited))
+	}
+	expected := []string{"testdb0", "testdb0.testtable0", "testdb0.testtable0/20220900"}
+	for i := range expected {
+		if v.visited[i] != expected[i] {
+			t.Fatalf("expected %s at %d, got %s", expected[i], i, v.visited[i])
+		}
+	}
+	if last := v.visited[len(v.visited)-1]; last != "testdb1.testtable1/20220902" {
+		t.Fatalf("expected testdb1.testtable1/20220902 last, got %s", last)
+	}
+}
+
+func TestWalkSkipChildren(t *testing.T) {
+	crawler := Crawler{Glue: newMockedCatalog(2, 2, 3)}
+	v := &recordingVisitor{
+		database: func(db *glue.Database) error {
+			if *db.Name == "testdb0" {
+				return SkipChildren
+			}
+			return nil
+		},
+		table: func(table *glue.TableData) error {
+			if *table.Name == "testtable0" {
+				return SkipChildren
+			}
+			return nil
+		},
+		partition: func(p *glue.Partition) error {
+			if *p.Values[0] == "20220901" {
+				return SkipChildren
+			}
+			return nil
+		},
+	}
+	err := crawler.Walk(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"testdb0",
+		"testdb1",
+		"testdb1.testtable0",
+		"testdb1.testtable1",
+		"testdb1.testtable1/20220900",
+		"testdb1.testtable1/20220901",
+	}
+	if len(v.visited) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, v.visited)
+	}
+	for i := range expected {
+		if v.visited[i] != expected[i] {
+			t.Fatalf("expected %s at %d, got %s", expected[i], i, v.visited[i])
+		}
+	}
+}
+
+func TestWalkSkipAll(t *testing.T) {
+	crawler := Crawler{Glue: newMockedCatalog(2, 2, 3)}
+	v := &recordingVisitor{
+		table: func(table *glue.TableData) error {
+			return SkipAll
+		},
+	}
+	err := crawler.Walk(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(v.visited) != 2 {
+		t.Fatalf("expected walk to stop after the first table, got %v", v.visited)
+	}
+}
+
+func TestWalkKeepGoing(t *testing.T) {
+	errBoom := errors.New("boom")
+	visitor := func() *recordingVisitor {
+		return &recordingVisitor{
+			table: func(table *glue.TableData) error {
+				if *table.Name == "testtable0" {
+					return errBoom
+				}
+				return nil
+			},
+		}
+	}
+
+	crawler := Crawler{Glue: newMockedCatalog(2, 2, 3)}
+	err := crawler.Walk(visitor())
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+
+	crawler.KeepGoing = true
+	v := visitor()
+	err = crawler.Walk(v)
+	var multiErr *MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("expected MultiError, got %v", err)
+	}
+	if len(multiErr.Errors) != 2 || multiErr.Errors[0].Object() != "testdb0.testtable0" {
+		t.Fatalf("expected failures for testtable0 in both databases, got %v", multiErr.Errors)
+	}
+	if len(v.visited) != 2+4+6 {
+		t.Fatalf("expected partitions of failed tables to be skipped, got %d visits", len(v.visited))
+	}
+}